@@ -1 +1,4 @@
 terraform import coveralls_repository.example github:dangernoodle-io/terraform-provider-coveralls
+
+# the service may be left out if the provider has a default_service
+terraform import coveralls_repository.example dangernoodle-io/terraform-provider-coveralls
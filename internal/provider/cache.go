@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"terraform-provider-coveralls/internal/provider/client"
+)
+
+const (
+	// defaultPrefetchTTL bounds how long a listing of an owner's repositories is used to serve reads.
+	defaultPrefetchTTL = 5 * time.Minute
+)
+
+// repositoryCache serves reads from listings of each owner's repositories, so refreshing many repositories with the
+// same owner needs a single request instead of one each. A repository missing from a listing, or from an owner that
+// couldn't be listed, is read individually. It is safe for concurrent use.
+type repositoryCache struct {
+	api client.API
+	ttl time.Duration
+	now func() time.Time
+
+	mu       sync.Mutex
+	listings map[string]*listing
+	// unsupported is set once the api reports that it can't list repositories, every read is then made individually
+	unsupported bool
+}
+
+// listing holds the repositories of an owner, ready is closed once they have been fetched. If the fetch failed it is
+// empty, so the reads waiting for it read their repositories individually.
+type listing struct {
+	ready        chan struct{}
+	fetched      time.Time
+	repositories map[string]*client.Repository
+}
+
+func newRepositoryCache(api client.API, ttl time.Duration) *repositoryCache {
+	return &repositoryCache{
+		api:      api,
+		ttl:      ttl,
+		now:      time.Now,
+		listings: map[string]*listing{},
+	}
+}
+
+// Get returns the repository from its owner's listing, fetching the listing if it isn't cached or has expired. Only
+// one listing of an owner is fetched at a time, concurrent reads wait for it.
+func (c *repositoryCache) Get(ctx context.Context, service, name string) (*client.Repository, error) {
+	owner := client.Owner(name)
+	if owner == "" {
+		return c.api.Get(ctx, service, name)
+	}
+
+	l := c.listing(ctx, service, owner)
+	if l == nil {
+		return c.api.Get(ctx, service, name)
+	}
+
+	select {
+	case <-l.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	c.mu.Lock()
+	repository, ok := l.repositories[strings.ToLower(name)]
+	c.mu.Unlock()
+
+	// a listing may omit the token, and won't include repositories created since it was fetched
+	if !ok || repository.Token == "" {
+		return c.api.Get(ctx, service, name)
+	}
+
+	tflog.Debug(ctx, "Read coveralls repository from prefetched listing", map[string]any{
+		"service": service,
+		"name":    name,
+	})
+
+	copied := *repository
+	return &copied, nil
+}
+
+// Invalidate discards the listing of a repository's owner once it has been written, as it may be out of date or
+// still being fetched. The next read fetches it again.
+func (c *repositoryCache) Invalidate(service, name string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.listings, service+":"+strings.ToLower(client.Owner(name)))
+}
+
+// listing returns the cached listing of an owner, starting a fetch if there isn't an unexpired one. It returns nil if
+// the api can't list repositories.
+func (c *repositoryCache) listing(ctx context.Context, service, owner string) *listing {
+	key := service + ":" + strings.ToLower(owner)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.unsupported {
+		return nil
+	}
+
+	if l, ok := c.listings[key]; ok && (l.fetched.IsZero() || c.now().Sub(l.fetched) < c.ttl) {
+		return l
+	}
+
+	l := &listing{ready: make(chan struct{})}
+	c.listings[key] = l
+
+	// the fetch outlives the read that started it as others may be waiting, it is bounded by the client timeouts
+	go c.fetch(context.WithoutCancel(ctx), key, l, service, owner)
+
+	return l
+}
+
+func (c *repositoryCache) fetch(ctx context.Context, key string, l *listing, service, owner string) {
+	ctx = tflog.SetField(ctx, "service", service)
+	ctx = tflog.SetField(ctx, "owner", owner)
+
+	repositories, err := c.api.List(ctx, service, owner)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(l.ready)
+
+	l.fetched = c.now()
+	l.repositories = map[string]*client.Repository{}
+
+	switch {
+	case errors.Is(err, client.ErrNotSupported):
+		// the api doesn't list repositories at all, so it isn't asked again
+		c.unsupported = true
+		tflog.Debug(ctx, "Coveralls api doesn't support listing repositories, reading them individually",
+			map[string]any{"error": err.Error()})
+		return
+	case errors.Is(err, client.ErrNotFound):
+		// the owner can't be listed, which is remembered for the ttl so it isn't asked on every read
+		tflog.Debug(ctx, "Unable to list coveralls repositories of owner, reading them individually",
+			map[string]any{"error": err.Error()})
+		return
+	case err != nil:
+		// other failures may be transient, eg: server errors, so the listing is fetched again by the next read. Reads
+		// never fail because of it, as listing repositories isn't part of the documented api.
+		tflog.Debug(ctx, "Failed to list coveralls repositories, reading them individually",
+			map[string]any{"error": err.Error()})
+		if c.listings[key] == l {
+			delete(c.listings, key)
+		}
+		return
+	}
+
+	// names are case-insensitive, see RepositoryNameType
+	for _, repository := range repositories {
+		l.repositories[strings.ToLower(repository.Name)] = repository
+	}
+
+	tflog.Debug(ctx, "Prefetched coveralls repositories", map[string]any{"count": len(repositories)})
+}
@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+	"terraform-provider-coveralls/internal/provider/client/fake"
+)
+
+func TestRepositoryCache(t *testing.T) {
+	ctx := t.Context()
+	api := fake.New()
+	for _, name := range []string{"owner/one", "owner/two", "owner/three"} {
+		api.AddRepository(&client.Repository{Service: "github", Name: name})
+	}
+
+	cache := newRepositoryCache(api, time.Minute)
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"owner/one", "owner/two", "owner/three", "owner/one"} {
+		wg.Go(func() {
+			repository, err := cache.Get(ctx, "github", name)
+			require.NoError(t, err)
+			require.Equal(t, name, repository.Name)
+			require.NotEmpty(t, repository.Token)
+		})
+	}
+	wg.Wait()
+
+	require.Equal(t, 1, api.Calls(fake.OperationList))
+	require.Equal(t, 0, api.Calls(fake.OperationGet))
+
+	// repositories that aren't in the listing are read individually
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/four"})
+
+	_, err := cache.Get(ctx, "github", "owner/four")
+	require.NoError(t, err)
+
+	_, err = cache.Get(ctx, "github", "owner/missing")
+	require.ErrorIs(t, err, client.ErrNotFound)
+
+	require.Equal(t, 1, api.Calls(fake.OperationList))
+	require.Equal(t, 2, api.Calls(fake.OperationGet))
+}
+
+func TestRepositoryCacheExpiry(t *testing.T) {
+	ctx := t.Context()
+	api := fake.New()
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	now := time.Now()
+	cache := newRepositoryCache(api, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	_, err := cache.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+
+	now = now.Add(59 * time.Second)
+	_, err = cache.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, 1, api.Calls(fake.OperationList))
+
+	now = now.Add(time.Second)
+	_, err = cache.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, 2, api.Calls(fake.OperationList))
+	require.Equal(t, 0, api.Calls(fake.OperationGet))
+}
+
+func TestRepositoryCacheInvalidate(t *testing.T) {
+	ctx := t.Context()
+	api := fake.New()
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	cache := newRepositoryCache(api, time.Minute)
+
+	_, err := cache.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+
+	_, err = api.Update(ctx, "github", "owner/repo", &client.RepositoryUpdate{SendBuildStatus: client.FieldOf(true)})
+	require.NoError(t, err)
+	cache.Invalidate("github", "owner/repo")
+
+	repository, err := cache.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+	require.True(t, repository.SendBuildStatus)
+	require.Equal(t, 2, api.Calls(fake.OperationList))
+
+	// a disabled cache is nil
+	var disabled *repositoryCache
+	disabled.Invalidate("github", "owner/repo")
+}
+
+func TestRepositoryCacheListError(t *testing.T) {
+	ctx := t.Context()
+	api := fake.New()
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	cache := newRepositoryCache(api, time.Minute)
+
+	// a failed listing never fails the read, the repository is read individually instead
+	for _, err := range []error{
+		&client.APIError{StatusCode: http.StatusUnauthorized},
+		&client.APIError{StatusCode: http.StatusInternalServerError},
+		errors.New("connection reset"),
+	} {
+		api.SetError(fake.OperationList, err)
+
+		repository, err := cache.Get(ctx, "github", "owner/repo")
+		require.NoError(t, err)
+		require.Equal(t, "owner/repo", repository.Name)
+	}
+
+	require.Equal(t, 3, api.Calls(fake.OperationGet))
+
+	// the failures aren't remembered, the next read fetches the listing again
+	api.SetError(fake.OperationList, nil)
+
+	_, err := cache.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, 4, api.Calls(fake.OperationList))
+	require.Equal(t, 3, api.Calls(fake.OperationGet))
+}
+
+func TestRepositoryCacheListNotFound(t *testing.T) {
+	ctx := t.Context()
+	api := fake.New()
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+	api.AddRepository(&client.Repository{Service: "github", Name: "other/repo"})
+	api.SetError(fake.OperationList, &client.APIError{StatusCode: http.StatusNotFound})
+
+	cache := newRepositoryCache(api, time.Minute)
+
+	for range 2 {
+		repository, err := cache.Get(ctx, "github", "owner/repo")
+		require.NoError(t, err)
+		require.Equal(t, "owner/repo", repository.Name)
+	}
+
+	// the failure is remembered for the owner, so the listing isn't requested again until it expires
+	require.Equal(t, 1, api.Calls(fake.OperationList))
+	require.Equal(t, 2, api.Calls(fake.OperationGet))
+
+	_, err := cache.Get(ctx, "github", "other/repo")
+	require.NoError(t, err)
+	require.Equal(t, 2, api.Calls(fake.OperationList))
+}
+
+func TestRepositoryCacheListNotSupported(t *testing.T) {
+	ctx := t.Context()
+	api := fake.New()
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+	api.AddRepository(&client.Repository{Service: "github", Name: "other/repo"})
+	api.SetError(fake.OperationList, &client.APIError{StatusCode: http.StatusMethodNotAllowed})
+
+	cache := newRepositoryCache(api, time.Minute)
+
+	for _, name := range []string{"owner/repo", "other/repo"} {
+		_, err := cache.Get(ctx, "github", name)
+		require.NoError(t, err)
+	}
+
+	// an api that can't list repositories isn't asked again, for any owner
+	require.Equal(t, 1, api.Calls(fake.OperationList))
+	require.Equal(t, 2, api.Calls(fake.OperationGet))
+}
+
+func TestRepositoryCacheNoOwner(t *testing.T) {
+	api := fake.New()
+	cache := newRepositoryCache(api, time.Minute)
+
+	_, err := cache.Get(t.Context(), "github", "repo")
+	require.ErrorIs(t, err, client.ErrNotFound)
+
+	require.Equal(t, 0, api.Calls(fake.OperationList))
+	require.Equal(t, 1, api.Calls(fake.OperationGet))
+}
@@ -0,0 +1,303 @@
+// Package cassette records http exchanges with the Coveralls api to a file and replays them, allowing acceptance
+// tests recorded once against the real api to run deterministically without credentials or network access.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// ModeEnv is the environment variable used to select the Mode, it defaults to ModeReplay.
+	ModeEnv = "COVERALLS_CASSETTE_MODE"
+
+	// Redacted replaces secrets in recorded exchanges.
+	Redacted = "REDACTED"
+)
+
+// Mode controls whether exchanges are recorded, replayed or sent to the api untouched.
+type Mode string
+
+const (
+	// ModeRecord sends requests to the api and records the exchanges, replacing any existing cassette when saved.
+	ModeRecord Mode = "record"
+	// ModeReplay answers requests from a previously recorded cassette without sending them.
+	ModeReplay Mode = "replay"
+	// ModePassthrough sends requests to the api without recording them.
+	ModePassthrough Mode = "passthrough"
+)
+
+var (
+	// repository tokens in request and response bodies, eg: '"token": "abc123"'
+	tokenRegex = regexp.MustCompile(`("(?:repo_)?token"\s*:\s*)"[^"]*"`)
+
+	// response headers that are never recorded
+	droppedHeaders = []string{"Set-Cookie"}
+)
+
+// ModeFromEnv returns the Mode selected by the ModeEnv environment variable.
+func ModeFromEnv() (Mode, error) {
+	value := strings.TrimSpace(os.Getenv(ModeEnv))
+	if value == "" {
+		return ModeReplay, nil
+	}
+
+	switch mode := Mode(strings.ToLower(value)); mode {
+	case ModeRecord, ModeReplay, ModePassthrough:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q: must be one of 'record', 'replay' or 'passthrough'", ModeEnv, value)
+	}
+}
+
+// Interaction is a single recorded exchange.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request holds the parts of a request that are matched when replaying.
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Query is encoded with its keys sorted, so it matches whatever order the parameters were added in
+	Query string `json:"query,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// Response holds the recorded response to a request.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Cassette records and replays exchanges, it is safe for concurrent use.
+type Cassette struct {
+	path    string
+	mode    Mode
+	secrets []string
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	replayed     []bool
+}
+
+// Load returns a cassette for the file at path. In ModeReplay the file must exist, an error matching os.ErrNotExist is
+// returned if it doesn't. Every occurrence of the given secrets, eg: the api token, is redacted from recorded exchanges.
+func Load(path string, mode Mode, secrets ...string) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode}
+	for _, secret := range secrets {
+		if secret != "" {
+			c.secrets = append(c.secrets, secret)
+		}
+	}
+
+	if mode != ModeReplay {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cassette: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &c.interactions); err != nil {
+		return nil, fmt.Errorf("unable to parse cassette %s: %w", path, err)
+	}
+	c.replayed = make([]bool, len(c.interactions))
+
+	return c, nil
+}
+
+// Mode returns the mode the cassette was loaded with.
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Interactions returns the exchanges recorded or loaded so far.
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	interactions := make([]Interaction, len(c.interactions))
+	for i, interaction := range c.interactions {
+		interactions[i] = *interaction
+	}
+	return interactions
+}
+
+// Transport wraps next so requests are recorded or replayed according to the cassette mode, it can be installed
+// using client.WithTransport.
+func (c *Cassette) Transport(next http.RoundTripper) http.RoundTripper {
+	switch c.mode {
+	case ModeRecord:
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return c.record(next, req)
+		})
+	case ModeReplay:
+		return roundTripperFunc(c.replay)
+	default:
+		return next
+	}
+}
+
+// Save writes the recorded exchanges to the cassette file, creating its directory if needed. It does nothing unless
+// the cassette is recording.
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("unable to create cassette directory: %w", err)
+	}
+
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+func (c *Cassette) record(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	request, err := c.request(req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := response.Header.Clone()
+	for _, key := range droppedHeaders {
+		header.Del(key)
+	}
+	for key, values := range header {
+		for i, value := range values {
+			values[i] = c.scrub(value)
+		}
+		header[key] = values
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, &Interaction{
+		Request: request,
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Header:     header,
+			Body:       c.scrub(string(body)),
+		},
+	})
+
+	return response, nil
+}
+
+// replay answers the request with the first matching interaction that hasn't already been replayed, so repeated
+// requests, eg: polling, receive their responses in the order they were recorded. Once they have all been replayed, a
+// GET receives the last of them again, as terraform may refresh more often than it did when recording.
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	request, err := c.request(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last := -1
+	for i, interaction := range c.interactions {
+		if interaction.Request != request {
+			continue
+		}
+		if !c.replayed[i] {
+			c.replayed[i] = true
+			return replayResponse(req, interaction), nil
+		}
+		last = i
+	}
+
+	if last >= 0 && request.Method == http.MethodGet {
+		return replayResponse(req, c.interactions[last]), nil
+	}
+
+	description := request.Method + " " + request.Path
+	if request.Query != "" {
+		description += "?" + request.Query
+	}
+
+	return nil, fmt.Errorf("cassette %s has no interaction for %s", c.path, description)
+}
+
+func replayResponse(req *http.Request, interaction *Interaction) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Response.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}
+}
+
+// request returns the scrubbed form of req that is recorded and matched, the request body is left readable.
+func (c *Cassette) request(req *http.Request) (Request, error) {
+	request := Request{
+		Method: req.Method,
+		Path:   c.scrub(req.URL.EscapedPath()),
+		Query:  c.scrub(req.URL.Query().Encode()),
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return request, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return request, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	request.Body = c.scrub(string(body))
+	return request, nil
+}
+
+func (c *Cassette) scrub(value string) string {
+	for _, secret := range c.secrets {
+		value = strings.ReplaceAll(value, secret, Redacted)
+	}
+	return tokenRegex.ReplaceAllString(value, `${1}"`+Redacted+`"`)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
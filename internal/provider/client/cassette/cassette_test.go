@@ -0,0 +1,186 @@
+package cassette
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+	"terraform-provider-coveralls/internal/provider/client/coverallstest"
+)
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv(ModeEnv, "")
+	mode, err := ModeFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, ModeReplay, mode)
+
+	t.Setenv(ModeEnv, " Record ")
+	mode, err = ModeFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, ModeRecord, mode)
+
+	t.Setenv(ModeEnv, "rewind")
+	_, err = ModeFromEnv()
+	require.ErrorContains(t, err, "invalid "+ModeEnv)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := coverallstest.NewServer(t)
+	path := filepath.Join(t.TempDir(), "cassettes", "repository.json")
+
+	recorder, err := Load(path, ModeRecord, coverallstest.Token)
+	require.NoError(t, err)
+
+	recorded := exercise(t, server.URL, coverallstest.Token, recorder)
+	require.NoError(t, recorder.Save())
+	require.Len(t, server.Requests(), 3)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), coverallstest.Token)
+	require.NotContains(t, string(data), recorded.Token)
+	require.Contains(t, recorder.Interactions()[2].Response.Body, `"token":"REDACTED"`)
+
+	// the endpoint and token don't need to match the recording, the server is never contacted
+	player, err := Load(path, ModeReplay)
+	require.NoError(t, err)
+	require.Len(t, player.Interactions(), 3)
+
+	replayed := exercise(t, "https://coveralls.invalid", "another-token", player)
+	require.Len(t, server.Requests(), 3)
+	require.Equal(t, Redacted, replayed.Token)
+	require.True(t, replayed.SendBuildStatus)
+}
+
+func TestReplayNoInteraction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	require.NoError(t, os.WriteFile(path, []byte("[]"), 0o644))
+
+	player, err := Load(path, ModeReplay)
+	require.NoError(t, err)
+
+	c, err := client.NewCoveralls("https://coveralls.invalid", "fake-token",
+		client.WithRetries(0, time.Millisecond), client.WithTransport(player.Transport))
+	require.NoError(t, err)
+
+	_, err = c.Get(t.Context(), "github", "owner/repo")
+	require.ErrorContains(t, err, "no interaction for GET /api/repos/github/owner/repo")
+}
+
+func TestReplayMatchesBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "update.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+  {
+    "request": {"method": "PUT", "path": "/api/repos/github/owner/repo", "body": "{\"repo\":{\"comment_on_pull_requests\":true}}"},
+    "response": {"status_code": 200, "body": "{\"repo\":{\"comment_on_pull_requests\":true}}"}
+  }
+]`), 0o644))
+
+	player, err := Load(path, ModeReplay)
+	require.NoError(t, err)
+
+	transport := player.Transport(nil)
+
+	req, err := http.NewRequest(http.MethodPut, "https://coveralls.invalid/api/repos/github/owner/repo",
+		strings.NewReader(`{"repo":{"comment_on_pull_requests":false}}`))
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	require.Error(t, err)
+
+	req, err = http.NewRequest(http.MethodPut, "https://coveralls.invalid/api/repos/github/owner/repo",
+		strings.NewReader(`{"repo":{"comment_on_pull_requests":true}}`))
+	require.NoError(t, err)
+	response, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	// each interaction is only replayed once
+	req, err = http.NewRequest(http.MethodPut, "https://coveralls.invalid/api/repos/github/owner/repo",
+		strings.NewReader(`{"repo":{"comment_on_pull_requests":true}}`))
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	require.Error(t, err)
+}
+
+func TestReplayMatchesQuery(t *testing.T) {
+	server := coverallstest.NewServer(t)
+	server.Fake().AddRepository(&client.Repository{Service: "github", Name: "one/repo"})
+	server.Fake().AddRepository(&client.Repository{Service: "github", Name: "two/repo"})
+	path := filepath.Join(t.TempDir(), "list.json")
+
+	recorder, err := Load(path, ModeRecord, coverallstest.Token)
+	require.NoError(t, err)
+
+	c, err := client.NewCoveralls(server.URL, coverallstest.Token,
+		client.WithRetries(0, time.Millisecond), client.WithTransport(recorder.Transport))
+	require.NoError(t, err)
+
+	for _, owner := range []string{"one", "two"} {
+		_, err = c.List(t.Context(), "github", owner)
+		require.NoError(t, err)
+	}
+	require.NoError(t, recorder.Save())
+	require.Equal(t, "owner=one&service=github", recorder.Interactions()[0].Request.Query)
+
+	player, err := Load(path, ModeReplay)
+	require.NoError(t, err)
+
+	c, err = client.NewCoveralls("https://coveralls.invalid", "fake-token",
+		client.WithRetries(0, time.Millisecond), client.WithTransport(player.Transport))
+	require.NoError(t, err)
+
+	// listings are replayed by owner rather than the order they were recorded in, and reads can be repeated
+	for _, owner := range []string{"two", "one", "two"} {
+		listed, err := c.List(t.Context(), "github", owner)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		require.Equal(t, owner+"/repo", listed[0].Name)
+	}
+
+	_, err = c.List(t.Context(), "github", "three")
+	require.ErrorContains(t, err, "no interaction for GET /api/repos?owner=three&service=github")
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestPassthrough(t *testing.T) {
+	server := coverallstest.NewServer(t)
+	path := filepath.Join(t.TempDir(), "passthrough.json")
+
+	passthrough, err := Load(path, ModePassthrough, coverallstest.Token)
+	require.NoError(t, err)
+
+	exercise(t, server.URL, coverallstest.Token, passthrough)
+	require.NoError(t, passthrough.Save())
+
+	require.Len(t, server.Requests(), 3)
+	require.Empty(t, passthrough.Interactions())
+	require.NoFileExists(t, path)
+}
+
+// exercise creates, updates and reads a repository using a client with the cassette installed.
+func exercise(t *testing.T, endpoint, token string, cassette *Cassette) *client.Repository {
+	c, err := client.NewCoveralls(endpoint, token,
+		client.WithRetries(0, time.Millisecond), client.WithTransport(cassette.Transport))
+	require.NoError(t, err)
+
+	_, err = c.Create(t.Context(), &client.RepositoryCreate{Service: "github", Name: "owner/repo"})
+	require.NoError(t, err)
+
+	_, err = c.Update(t.Context(), "github", "owner/repo", &client.RepositoryUpdate{SendBuildStatus: client.FieldOf(true)})
+	require.NoError(t, err)
+
+	repository, err := c.Get(t.Context(), "github", "owner/repo")
+	require.NoError(t, err)
+
+	return repository
+}
@@ -4,21 +4,48 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 const (
-	ContentType = "application/json; charset=utf-8"
+	ContentType           = "application/json; charset=utf-8"
+	DefaultEndpoint       = "https://coveralls.io"
+	DefaultRequestTimeout = time.Minute
 )
 
+// API is implemented by Client, and by fakes used for testing, covering every operation supported by the Coveralls
+// api.
+type API interface {
+	Create(ctx context.Context, create *RepositoryCreate) (*Repository, error)
+	Delete(ctx context.Context, service, name string) error
+	Get(ctx context.Context, service, name string) (*Repository, error)
+	List(ctx context.Context, service, owner string) ([]*Repository, error)
+	Update(ctx context.Context, service, name string, update *RepositoryUpdate) (*Repository, error)
+	ValidateCredentials(ctx context.Context) error
+}
+
+var _ API = &Client{}
+
 type Client struct {
 	resty    *resty.Client
 	endpoint *url.URL
+	// transport is the underlying http transport, options that configure the connection modify it directly
+	transport *http.Transport
+	// transports wrap the underlying http transport, they are applied once all options have been processed
+	transports []func(http.RoundTripper) http.RoundTripper
+	// cache holds repositories that have been read, so reads can be made conditional
+	cache conditionalCache
 }
 
+// Option configures optional behaviour of the Client created by NewCoveralls.
+type Option func(*Client) error
+
 type Repository struct {
 	Service               string   `json:"service,omitempty"`
 	Name                  string   `json:"name,omitempty"`
@@ -35,22 +62,132 @@ type body struct {
 	Repo *Repository `json:"repo"`
 }
 
-func NewCoveralls(endpoint, token string) (*Client, error) {
+type listBody struct {
+	Repos []*Repository `json:"repos"`
+}
+
+func NewCoveralls(endpoint, token string, opts ...Option) (*Client, error) {
 	client := resty.New()
 	client.SetHeader("Accept", ContentType)
 	client.SetHeader("Content-Type", ContentType)
 	client.SetHeader("Authorization", fmt.Sprintf("token %s", token))
 
-	u, _ := url.Parse(endpoint)
+	u, err := ParseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := client.Transport()
+	if err != nil {
+		return nil, err
+	}
+
+	coveralls := &Client{resty: client, endpoint: u, transport: transport}
+
+	opts = append([]Option{
+		WithRetries(DefaultMaxRetries, DefaultRetryMaxWait),
+		WithRequestTimeout(DefaultRequestTimeout),
+	}, opts...)
+	for _, opt := range opts {
+		if err := opt(coveralls); err != nil {
+			return nil, err
+		}
+	}
+
+	// tracing is innermost so each attempt is logged with the time actually spent on the wire
+	var roundTripper http.RoundTripper = newTraceTransport(transport, token)
+	for _, wrap := range coveralls.transports {
+		roundTripper = wrap(roundTripper)
+	}
+	client.SetTransport(roundTripper)
+
+	return coveralls, nil
+}
+
+// WithRequestTimeout limits the time a single attempt of a request may take, including reading the response body.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(client *Client) error {
+		if timeout <= 0 {
+			return errors.New("request timeout must be greater than zero")
+		}
+
+		client.resty.SetTimeout(timeout)
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(client *Client) error {
+		client.resty.SetHeader("User-Agent", userAgent)
+		return nil
+	}
+}
+
+// WithTransport wraps the http transport used to send requests, eg: to record or replay them in tests. Wrappers are
+// applied in the order options are given, so one given after the other options sees every request they send.
+func WithTransport(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(client *Client) error {
+		if wrap == nil {
+			return errors.New("transport wrapper must not be nil")
+		}
 
-	return &Client{client, u}, nil
+		client.transports = append(client.transports, wrap)
+		return nil
+	}
+}
+
+// ParseEndpoint validates that endpoint is an absolute http(s) url and normalizes it so that api paths can be
+// appended to it, eg: 'https://coveralls.example.com/coveralls/' becomes 'https://coveralls.example.com/coveralls'.
+func ParseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme must be 'http' or 'https'", endpoint)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: missing host", endpoint)
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: query, fragment and user info are not supported", endpoint)
+	}
+
+	// collapse any duplicate slashes and strip the trailing one
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	u.Path = ""
+	u.RawPath = ""
+	if len(segments) > 0 {
+		u.Path = "/" + strings.Join(segments, "/")
+	}
+
+	return u, nil
 }
 
-func (client *Client) Create(ctx context.Context, repository *Repository) (*Repository, error) {
-	ctx = tflog.SetField(ctx, "repository", repository)
+// Owner returns the owner of a repository name, which is everything before the last '/', eg: 'group/subgroup' for
+// 'group/subgroup/repo'. An empty string is returned if the name has no owner.
+func Owner(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// Create adds the repository with the settings that are set in the request.
+func (client *Client) Create(ctx context.Context, create *RepositoryCreate) (*Repository, error) {
+	ctx = tflog.SetField(ctx, "service", create.Service)
+	ctx = tflog.SetField(ctx, "name", create.Name)
 	tflog.Debug(ctx, "Creating coveralls repository")
 
-	response, err := requestWithBody(ctx, client, repository).
+	response, err := client.resty.R().
+		SetContext(ctx).
+		SetBody(createBody{create}).
+		SetResult(body{}).
 		Post(fmt.Sprintf("%s/api/repos", client.endpoint.String()))
 
 	if err != nil {
@@ -69,21 +206,59 @@ func (client *Client) Create(ctx context.Context, repository *Repository) (*Repo
 	return result.Repo, nil
 }
 
+// Delete removes the repository. Not every installation allows repositories to be deleted using the api, an error
+// matching ErrNotSupported is returned by those that don't.
+func (client *Client) Delete(ctx context.Context, service, name string) error {
+	ctx = tflog.SetField(ctx, "service", service)
+	ctx = tflog.SetField(ctx, "name", name)
+	tflog.Debug(ctx, "Deleting coveralls repository")
+
+	response, err := client.resty.R().
+		SetContext(ctx).
+		Delete(fmt.Sprintf("%s/api/repos/%s/%s", client.endpoint.String(), service, name))
+
+	if err != nil {
+		return err
+	}
+
+	client.cache.delete(service, name)
+
+	if response.IsError() {
+		return handleErrorResponse(ctx, response)
+	}
+
+	return nil
+}
+
 func (client *Client) Get(ctx context.Context, service, name string) (*Repository, error) {
 	ctx = tflog.SetField(ctx, "service", service)
 	ctx = tflog.SetField(ctx, "name", name)
 	tflog.Debug(ctx, "Retrieving coveralls repository")
 
-	response, err := client.resty.R().
+	request := client.resty.R().
 		SetContext(ctx).
-		SetResult(Repository{}).
-		Get(fmt.Sprintf("%s/api/repos/%s/%s", client.endpoint.String(), service, name))
+		SetResult(Repository{})
+
+	cached := client.cache.get(service, name)
+	if cached != nil {
+		setConditionalHeaders(request.Header, cached.validators)
+	}
+
+	response, err := request.Get(fmt.Sprintf("%s/api/repos/%s/%s", client.endpoint.String(), service, name))
 
 	if err != nil {
 		return nil, err
 	}
 
+	if response.StatusCode() == http.StatusNotModified && cached != nil {
+		tflog.Debug(ctx, "Coveralls repository not modified, using cached copy")
+		return cached.repository, nil
+	}
+
 	if response.IsError() {
+		if response.StatusCode() == http.StatusNotFound {
+			client.cache.delete(service, name)
+		}
 		return nil, handleErrorResponse(ctx, response)
 	}
 
@@ -91,17 +266,73 @@ func (client *Client) Get(ctx context.Context, service, name string) (*Repositor
 	if !ok {
 		return nil, errors.New("unexpected response format: couldn't convert to body type")
 	}
+
+	// the identifiers are known from the request if the response omits them
+	if result.Service == "" {
+		result.Service = service
+	}
+	if result.Name == "" {
+		result.Name = name
+	}
+
+	if validators := responseValidators(response.Header()); !validators.IsEmpty() {
+		client.cache.set(service, name, validators, result)
+	} else {
+		client.cache.delete(service, name)
+	}
+
 	return result, nil
 }
 
-func (client *Client) Update(ctx context.Context, service, name string, repository *Repository) (*Repository, error) {
-	response, err := requestWithBody(ctx, client, repository).
+// List returns the repositories belonging to an owner, eg: 'jgangemi' or a nested group such as 'group/subgroup'.
+func (client *Client) List(ctx context.Context, service, owner string) ([]*Repository, error) {
+	ctx = tflog.SetField(ctx, "service", service)
+	ctx = tflog.SetField(ctx, "owner", owner)
+	tflog.Debug(ctx, "Listing coveralls repositories")
+
+	response, err := client.resty.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{"service": service, "owner": owner}).
+		SetResult(listBody{}).
+		Get(fmt.Sprintf("%s/api/repos", client.endpoint.String()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.IsError() {
+		return nil, handleErrorResponse(ctx, response)
+	}
+
+	result, ok := response.Result().(*listBody)
+	if !ok {
+		return nil, errors.New("unexpected response format: couldn't convert to body type")
+	}
+
+	for _, repository := range result.Repos {
+		if repository.Service == "" {
+			repository.Service = service
+		}
+	}
+
+	return result.Repos, nil
+}
+
+// Update changes the settings that are set in the update, leaving the others unchanged.
+func (client *Client) Update(ctx context.Context, service, name string, update *RepositoryUpdate) (*Repository, error) {
+	response, err := client.resty.R().
+		SetContext(ctx).
+		SetBody(updateBody{update}).
+		SetResult(body{}).
 		Put(fmt.Sprintf("%s/api/repos/%s/%s", client.endpoint.String(), service, name))
 
 	if err != nil {
 		return nil, err
 	}
 
+	// the cached copy is out of date whatever the outcome
+	client.cache.delete(service, name)
+
 	if response.IsError() {
 		return nil, handleErrorResponse(ctx, response)
 	}
@@ -114,23 +345,37 @@ func (client *Client) Update(ctx context.Context, service, name string, reposito
 	return result.Repo, nil
 }
 
-func handleErrorResponse(ctx context.Context, response *resty.Response) error {
-	statusCode := response.StatusCode()
+// ValidateCredentials makes a lightweight authenticated request to check the api token is accepted. It returns nil only
+// for a successful response, and an error matching ErrUnauthorized or ErrForbidden if the token is rejected. Other
+// errors leave the token unchecked, eg: ErrNotFound from an installation that can't list repositories, as it may not
+// check the token first, or ErrRateLimited. The response body is ignored.
+func (client *Client) ValidateCredentials(ctx context.Context) error {
+	tflog.Debug(ctx, "Validating coveralls credentials")
 
-	ctx = tflog.SetField(ctx, "status_code", statusCode)
-	ctx = tflog.SetField(ctx, "error_message", response.String())
-	tflog.Debug(ctx, "Error response received")
+	response, err := client.resty.R().
+		SetContext(ctx).
+		Get(fmt.Sprintf("%s/api/repos", client.endpoint.String()))
 
-	if statusCode == 404 {
-		return errors.New("repository not found")
+	if err != nil {
+		return err
 	}
 
-	return errors.New(response.String())
+	if response.IsError() {
+		return handleErrorResponse(ctx, response)
+	}
+
+	return nil
 }
 
-func requestWithBody(ctx context.Context, client *Client, repository *Repository) *resty.Request {
-	return client.resty.R().
-		SetContext(ctx).
-		SetBody(body{repository}).
-		SetResult(body{})
+func handleErrorResponse(ctx context.Context, response *resty.Response) error {
+	statusCode := response.StatusCode()
+
+	err := newAPIError(statusCode, response.Header(), response.Body())
+
+	// the raw body is traced by the 'coveralls_http' subsystem with any secrets masked
+	ctx = tflog.SetField(ctx, "status_code", statusCode)
+	ctx = tflog.SetField(ctx, "request_id", err.RequestID)
+	tflog.Debug(ctx, "Error response received")
+
+	return err
 }
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Validators identify the version of a repository returned by the api, they are sent with subsequent reads so that
+// an unchanged repository isn't downloaded again.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// IsEmpty returns true if the api didn't return any validators.
+func (v Validators) IsEmpty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// ValidatorCache is implemented by clients that make conditional reads, allowing the validators of a repository to be
+// persisted and restored, eg: in resource private state, so that they survive between provider processes.
+type ValidatorCache interface {
+	// CachedValidators returns the validators of the last read of the repository.
+	CachedValidators(service, name string) (Validators, bool)
+	// SeedCache stores validators and the repository they apply to, to be returned if the api reports it unchanged.
+	SeedCache(service, name string, validators Validators, repository *Repository)
+}
+
+var _ ValidatorCache = &Client{}
+
+type cachedRepository struct {
+	validators Validators
+	repository *Repository
+}
+
+// conditionalCache holds the validators and repository of each read, keyed by 'service/name'.
+type conditionalCache struct {
+	mu           sync.Mutex
+	repositories map[string]*cachedRepository
+}
+
+func (client *Client) CachedValidators(service, name string) (Validators, bool) {
+	cached := client.cache.get(service, name)
+	if cached == nil {
+		return Validators{}, false
+	}
+	return cached.validators, true
+}
+
+func (client *Client) SeedCache(service, name string, validators Validators, repository *Repository) {
+	if validators.IsEmpty() || repository == nil {
+		return
+	}
+	client.cache.set(service, name, validators, repository)
+}
+
+func (c *conditionalCache) get(service, name string) *cachedRepository {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.repositories[service+"/"+name]
+	if !ok {
+		return nil
+	}
+	return &cachedRepository{validators: cached.validators, repository: copyRepository(cached.repository)}
+}
+
+func (c *conditionalCache) set(service, name string, validators Validators, repository *Repository) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.repositories == nil {
+		c.repositories = map[string]*cachedRepository{}
+	}
+	c.repositories[service+"/"+name] = &cachedRepository{validators: validators, repository: copyRepository(repository)}
+}
+
+func (c *conditionalCache) delete(service, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.repositories, service+"/"+name)
+}
+
+// setConditionalHeaders asks the api to only return the repository if it has changed since it was cached.
+func setConditionalHeaders(header http.Header, validators Validators) {
+	if validators.ETag != "" {
+		header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		header.Set("If-Modified-Since", validators.LastModified)
+	}
+}
+
+func responseValidators(header http.Header) Validators {
+	return Validators{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+}
+
+func copyRepository(repository *Repository) *Repository {
+	result := *repository
+	if repository.FailThreshold != nil {
+		v := *repository.FailThreshold
+		result.FailThreshold = &v
+	}
+	if repository.FailChangeThreshold != nil {
+		v := *repository.FailChangeThreshold
+		result.FailChangeThreshold = &v
+	}
+	return &result
+}
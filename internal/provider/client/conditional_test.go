@@ -0,0 +1,110 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalGet(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.Method == http.MethodPut {
+			_ = json.NewEncoder(w).Encode(body{&Repository{}})
+			return
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Wed, 21 Oct 2026 07:28:00 GMT")
+		_ = json.NewEncoder(w).Encode(&Repository{Token: "token", SendBuildStatus: true})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewCoveralls(server.URL, "fake-token", WithRetries(0, time.Millisecond))
+	require.NoError(t, err)
+
+	first, err := client.Get(t.Context(), "github", "owner/repo")
+	require.NoError(t, err)
+
+	validators, ok := client.CachedValidators("github", "owner/repo")
+	require.True(t, ok)
+	require.Equal(t, Validators{ETag: `"v1"`, LastModified: "Wed, 21 Oct 2026 07:28:00 GMT"}, validators)
+
+	second, err := client.Get(t.Context(), "github", "owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	require.Equal(t, int32(1), notModified.Load())
+
+	// updating the repository discards the cached copy
+	_, err = client.Update(t.Context(), "github", "owner/repo", &RepositoryUpdate{})
+	require.NoError(t, err)
+
+	_, ok = client.CachedValidators("github", "owner/repo")
+	require.False(t, ok)
+
+	_, err = client.Get(t.Context(), "github", "owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), notModified.Load())
+	require.Equal(t, int32(4), requests.Load())
+}
+
+func TestConditionalGetSeeded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, `"v2"`, r.Header.Get("If-None-Match"))
+		require.Equal(t, "Wed, 21 Oct 2026 07:28:00 GMT", r.Header.Get("If-Modified-Since"))
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewCoveralls(server.URL, "fake-token", WithRetries(0, time.Millisecond))
+	require.NoError(t, err)
+
+	threshold := 80.0
+	seeded := &Repository{Service: "github", Name: "owner/repo", Token: "token", FailThreshold: &threshold}
+	client.SeedCache("github", "owner/repo",
+		Validators{ETag: `"v2"`, LastModified: "Wed, 21 Oct 2026 07:28:00 GMT"}, seeded)
+
+	// the cache holds a copy
+	threshold = 10.0
+
+	got, err := client.Get(t.Context(), "github", "owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, "token", got.Token)
+	require.Equal(t, 80.0, *got.FailThreshold)
+}
+
+func TestConditionalGetWithoutValidators(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Empty(t, r.Header.Get("If-None-Match"))
+		_ = json.NewEncoder(w).Encode(&Repository{Token: "token"})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewCoveralls(server.URL, "fake-token", WithRetries(0, time.Millisecond))
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = client.Get(t.Context(), "github", "owner/repo")
+		require.NoError(t, err)
+	}
+
+	_, ok := client.CachedValidators("github", "owner/repo")
+	require.False(t, ok)
+
+	client.SeedCache("github", "owner/repo", Validators{}, &Repository{})
+	_, ok = client.CachedValidators("github", "owner/repo")
+	require.False(t, ok)
+}
@@ -0,0 +1,254 @@
+// Package coverallstest provides a local stand-in for the Coveralls api, allowing acceptance tests to run without
+// credentials or network access.
+package coverallstest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"terraform-provider-coveralls/internal/provider/client"
+	"terraform-provider-coveralls/internal/provider/client/fake"
+)
+
+const (
+	// Token is the api token accepted by servers created with NewServer.
+	Token = "coverallstest-token"
+)
+
+// Server implements the '/api/repos' endpoints of the Coveralls api, backed by an in-memory fake.
+type Server struct {
+	*httptest.Server
+
+	api *fake.Coveralls
+
+	mu       sync.Mutex
+	failures []*failure
+	requests []string
+	// deletable is set by EnableDelete
+	deletable bool
+}
+
+type failure struct {
+	method    string
+	path      string
+	status    int
+	body      string
+	remaining int
+}
+
+type body struct {
+	Repo *client.Repository `json:"repo"`
+}
+
+type createBody struct {
+	Repo *client.RepositoryCreate `json:"repo"`
+}
+
+type updateBody struct {
+	Repo *client.RepositoryUpdate `json:"repo"`
+}
+
+// NewServer starts a server that is closed when the test completes.
+func NewServer(t testing.TB) *Server {
+	s := &Server{api: fake.New()}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/repos", s.create)
+	mux.HandleFunc("GET /api/repos", s.list)
+	mux.HandleFunc("GET /api/repos/{service}/{name...}", s.get)
+	mux.HandleFunc("PUT /api/repos/{service}/{name...}", s.update)
+	mux.HandleFunc("DELETE /api/repos/{service}/{name...}", s.delete)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// EnableDelete allows repositories to be deleted, as some Coveralls Enterprise installations do. By default deleting a
+// repository answers 404 and leaves it in place, like coveralls.io, which has no documented delete endpoint.
+func (s *Server) EnableDelete() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deletable = true
+}
+
+// Fake returns the fake backing the server, for inspecting or seeding its state.
+func (s *Server) Fake() *fake.Coveralls {
+	return s.api
+}
+
+// Fail causes the next 'times' requests matching the method and path prefix to fail with the status code and a json
+// error body. A negative 'times' fails every matching request.
+func (s *Server) Fail(method, pathPrefix string, status, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &failure{
+		method:    method,
+		path:      pathPrefix,
+		status:    status,
+		body:      `{"error": "` + http.StatusText(status) + `"}`,
+		remaining: times,
+	})
+}
+
+// Requests returns the 'METHOD path' of every request received, in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		injected := s.injectedFailure(r)
+		s.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "token "+Token {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid API token"})
+			return
+		}
+
+		if injected != nil {
+			w.Header().Set("Content-Type", client.ContentType)
+			w.WriteHeader(injected.status)
+			_, _ = w.Write([]byte(injected.body))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// injectedFailure returns the failure matching the request, if any, the lock must be held.
+func (s *Server) injectedFailure(r *http.Request) *failure {
+	for i, f := range s.failures {
+		if f.method != r.Method || !strings.HasPrefix(r.URL.Path, f.path) {
+			continue
+		}
+
+		if f.remaining > 0 {
+			f.remaining--
+			if f.remaining == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+		}
+
+		return f
+	}
+
+	return nil
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	request := &createBody{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil || request.Repo == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	repository, err := s.api.Create(r.Context(), request.Repo)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, body{repository})
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	deletable := s.deletable
+	s.mu.Unlock()
+
+	if !deletable {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": http.StatusText(http.StatusNotFound)})
+		return
+	}
+
+	if err := s.api.Delete(r.Context(), r.PathValue("service"), r.PathValue("name")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	repository, err := s.api.Get(r.Context(), r.PathValue("service"), r.PathValue("name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// unchanged repositories aren't returned to conditional requests, which the client makes once it has read them
+	data, _ := json.Marshal(repository)
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(data))
+
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, repository)
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	repositories, err := s.api.List(r.Context(), r.URL.Query().Get("service"), r.URL.Query().Get("owner"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"repos": repositories})
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request) {
+	request := &updateBody{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil || request.Repo == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	repository, err := s.api.Update(r.Context(), r.PathValue("service"), r.PathValue("name"), request.Repo)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, body{repository})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	message := http.StatusText(apiErr.StatusCode)
+	if len(apiErr.Messages) > 0 {
+		message = strings.Join(apiErr.Messages, "; ")
+	}
+
+	writeJSON(w, apiErr.StatusCode, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", client.ContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,131 @@
+package coverallstest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+)
+
+func TestServer(t *testing.T) {
+	ctx := t.Context()
+	server := NewServer(t)
+
+	coveralls, err := client.NewCoveralls(server.URL, Token)
+	require.NoError(t, err)
+
+	created, err := coveralls.Create(ctx, &client.RepositoryCreate{Service: "gitlab", Name: "group/subgroup/repo"})
+	require.NoError(t, err)
+	require.Equal(t, "group/subgroup/repo", created.Name)
+	require.Empty(t, created.Token)
+
+	got, err := coveralls.Get(ctx, "gitlab", "group/subgroup/repo")
+	require.NoError(t, err)
+	require.NotEmpty(t, got.Token)
+	require.NotEmpty(t, got.CreatedAt)
+
+	_, err = coveralls.Update(ctx, "gitlab", "group/subgroup/repo", &client.RepositoryUpdate{CommentOnPullRequests: client.FieldOf(true)})
+	require.NoError(t, err)
+
+	stored, ok := server.Fake().Repository("gitlab", "group/subgroup/repo")
+	require.True(t, ok)
+	require.True(t, stored.CommentOnPullRequests)
+
+	_, err = coveralls.Create(ctx, &client.RepositoryCreate{Service: "gitlab", Name: "group/subgroup/repo"})
+	require.ErrorIs(t, err, client.ErrValidation)
+
+	_, err = coveralls.Get(ctx, "gitlab", "group/missing")
+	require.ErrorIs(t, err, client.ErrNotFound)
+
+	listed, err := coveralls.List(ctx, "gitlab", "group/subgroup")
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, got.Token, listed[0].Token)
+
+	// like coveralls.io, repositories can't be deleted unless enabled
+	require.ErrorIs(t, coveralls.Delete(ctx, "gitlab", "group/subgroup/repo"), client.ErrNotFound)
+	_, ok = server.Fake().Repository("gitlab", "group/subgroup/repo")
+	require.True(t, ok)
+
+	server.EnableDelete()
+	require.NoError(t, coveralls.Delete(ctx, "gitlab", "group/subgroup/repo"))
+	_, ok = server.Fake().Repository("gitlab", "group/subgroup/repo")
+	require.False(t, ok)
+
+	require.NoError(t, coveralls.ValidateCredentials(ctx))
+
+	require.Equal(t, []string{
+		"POST /api/repos",
+		"GET /api/repos/gitlab/group/subgroup/repo",
+		"PUT /api/repos/gitlab/group/subgroup/repo",
+		"POST /api/repos",
+		"GET /api/repos/gitlab/group/missing",
+		"GET /api/repos",
+		"DELETE /api/repos/gitlab/group/subgroup/repo",
+		"DELETE /api/repos/gitlab/group/subgroup/repo",
+		"GET /api/repos",
+	}, server.Requests())
+}
+
+func TestServerConditionalGet(t *testing.T) {
+	ctx := t.Context()
+	server := NewServer(t)
+	server.Fake().AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	coveralls, err := client.NewCoveralls(server.URL, Token)
+	require.NoError(t, err)
+
+	first, err := coveralls.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+
+	validators, ok := coveralls.CachedValidators("github", "owner/repo")
+	require.True(t, ok)
+	require.NotEmpty(t, validators.ETag)
+
+	second, err := coveralls.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	_, err = coveralls.Update(ctx, "github", "owner/repo", &client.RepositoryUpdate{SendBuildStatus: client.FieldOf(true)})
+	require.NoError(t, err)
+
+	third, err := coveralls.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+	require.True(t, third.SendBuildStatus)
+}
+
+func TestServerUnauthorized(t *testing.T) {
+	server := NewServer(t)
+
+	coveralls, err := client.NewCoveralls(server.URL, "wrong-token")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, coveralls.ValidateCredentials(t.Context()), client.ErrUnauthorized)
+}
+
+func TestServerFail(t *testing.T) {
+	ctx := t.Context()
+	server := NewServer(t)
+	server.Fake().AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	coveralls, err := client.NewCoveralls(server.URL, Token, client.WithRetries(0, time.Millisecond))
+	require.NoError(t, err)
+
+	server.Fail(http.MethodGet, "/api/repos/github/", http.StatusTooManyRequests, 1)
+
+	_, err = coveralls.Get(ctx, "github", "owner/repo")
+	require.ErrorIs(t, err, client.ErrRateLimited)
+
+	_, err = coveralls.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+
+	server.Fail(http.MethodPut, "/api/repos", http.StatusInternalServerError, -1)
+
+	for range 2 {
+		_, err = coveralls.Update(ctx, "github", "owner/repo", &client.RepositoryUpdate{})
+		require.Error(t, err)
+	}
+}
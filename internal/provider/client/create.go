@@ -0,0 +1,13 @@
+package client
+
+// RepositoryCreate adds a repository. Like an update only the settings that are set are sent, the others are left at
+// the defaults of Coveralls.
+type RepositoryCreate struct {
+	Service string `json:"service"`
+	Name    string `json:"name"`
+	RepositoryUpdate
+}
+
+type createBody struct {
+	Repo *RepositoryCreate `json:"repo"`
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNotFound     = errors.New("repository not found")
+	ErrUnauthorized = errors.New("unauthorized: check the api token")
+	ErrForbidden    = errors.New("forbidden: the api token does not have access")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrValidation   = errors.New("validation failed")
+	ErrNotSupported = errors.New("operation not supported")
+)
+
+// APIError is returned for any non-successful response from the Coveralls api. Use errors.Is with one of the sentinel
+// errors to determine the kind of failure, or errors.As to access the details.
+type APIError struct {
+	StatusCode int
+	Messages   []string
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "coveralls api error (%d %s)", e.StatusCode, http.StatusText(e.StatusCode))
+
+	if sentinel := e.sentinel(); sentinel != nil {
+		fmt.Fprintf(&sb, ": %s", sentinel)
+	}
+
+	if len(e.Messages) > 0 {
+		fmt.Fprintf(&sb, ": %s", strings.Join(e.Messages, "; "))
+	}
+
+	if e.RequestID != "" {
+		fmt.Fprintf(&sb, " [request id: %s]", e.RequestID)
+	}
+
+	return sb.String()
+}
+
+func (e *APIError) Is(target error) bool {
+	sentinel := e.sentinel()
+	return sentinel != nil && sentinel == target
+}
+
+func (e *APIError) sentinel() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ErrValidation
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return ErrNotSupported
+	}
+
+	return nil
+}
+
+func newAPIError(statusCode int, header http.Header, body []byte) *APIError {
+	return &APIError{
+		StatusCode: statusCode,
+		Messages:   parseErrorMessages(body),
+		RequestID:  header.Get("X-Request-Id"),
+	}
+}
+
+// parseErrorMessages extracts the error messages from a response body, supporting the '{"error": "..."}',
+// '{"errors": [...]}', '{"errors": {"field": [...]}}' and '{"message": "..."}' formats. Bodies that aren't json are
+// returned as is.
+func parseErrorMessages(body []byte) []string {
+	text := strings.TrimSpace(string(body))
+	if text == "" {
+		return nil
+	}
+
+	var document map[string]any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return []string{text}
+	}
+
+	var messages []string
+	for _, key := range []string{"error", "errors", "message"} {
+		messages = append(messages, flattenMessages("", document[key])...)
+	}
+
+	return messages
+}
+
+func flattenMessages(prefix string, value any) []string {
+	var messages []string
+
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			messages = append(messages, prefix+v)
+		}
+	case []any:
+		for _, item := range v {
+			messages = append(messages, flattenMessages(prefix, item)...)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			messages = append(messages, flattenMessages(prefix+key+" ", v[key])...)
+		}
+	}
+
+	return messages
+}
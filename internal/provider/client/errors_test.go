@@ -0,0 +1,85 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := map[int]error{
+		http.StatusBadRequest:          ErrValidation,
+		http.StatusUnauthorized:        ErrUnauthorized,
+		http.StatusForbidden:           ErrForbidden,
+		http.StatusNotFound:            ErrNotFound,
+		http.StatusUnprocessableEntity: ErrValidation,
+		http.StatusTooManyRequests:     ErrRateLimited,
+		http.StatusMethodNotAllowed:    ErrNotSupported,
+		http.StatusNotImplemented:      ErrNotSupported,
+	}
+
+	for status, want := range tests {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: status})
+
+			require.ErrorIs(t, err, want)
+
+			for _, other := range tests {
+				if other != want {
+					require.NotErrorIs(t, err, other)
+				}
+			}
+		})
+	}
+
+	require.NotErrorIs(t, &APIError{StatusCode: http.StatusInternalServerError}, ErrNotFound)
+}
+
+func TestAPIErrorResponse(t *testing.T) {
+	client := setup(t)
+
+	responder := getResponder(t, 422, map[string]any{
+		"errors": map[string]any{
+			"name":    []string{"can't be blank"},
+			"service": "is invalid",
+		},
+	}).HeaderSet(http.Header{"X-Request-Id": {"abc-123"}})
+
+	httpmock.RegisterResponder("GET", "https://coveralls.io/api/repos/github/username/reponame", responder)
+
+	_, err := client.Get(t.Context(), "github", "username/reponame")
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.ErrorIs(t, err, ErrValidation)
+	require.Equal(t, 422, apiErr.StatusCode)
+	require.Equal(t, []string{"name can't be blank", "service is invalid"}, apiErr.Messages)
+	require.Equal(t, "abc-123", apiErr.RequestID)
+	require.Equal(t, "coveralls api error (422 Unprocessable Entity): validation failed: "+
+		"name can't be blank; service is invalid [request id: abc-123]", err.Error())
+}
+
+func TestParseErrorMessages(t *testing.T) {
+	tests := map[string][]string{
+		``:                                      nil,
+		`   `:                                   nil,
+		`<html>Bad Gateway</html>`:              {"<html>Bad Gateway</html>"},
+		`{}`:                                    nil,
+		`{"error": "Invalid token"}`:            {"Invalid token"},
+		`{"message": "Not found"}`:              {"Not found"},
+		`{"errors": ["one", "two"]}`:            {"one", "two"},
+		`{"errors": {"name": ["is taken"]}}`:    {"name is taken"},
+		`{"error": "one", "message": "two"}`:    {"one", "two"},
+		`{"errors": [{"detail": "nested"}, 1]}`: {"detail nested"},
+	}
+
+	for body, want := range tests {
+		t.Run(body, func(t *testing.T) {
+			require.Equal(t, want, parseErrorMessages([]byte(body)))
+		})
+	}
+}
@@ -0,0 +1,285 @@
+// Package fake provides an in-memory implementation of client.API for testing resources and data sources without
+// a network connection.
+package fake
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"terraform-provider-coveralls/internal/provider/client"
+)
+
+// Operation identifies a method of client.API, used to inject errors and count calls.
+type Operation string
+
+const (
+	OperationCreate   Operation = "create"
+	OperationDelete   Operation = "delete"
+	OperationGet      Operation = "get"
+	OperationList     Operation = "list"
+	OperationUpdate   Operation = "update"
+	OperationValidate Operation = "validate"
+)
+
+var _ client.API = &Coveralls{}
+
+// Coveralls models the repositories held by Coveralls. It is safe for concurrent use.
+type Coveralls struct {
+	mu           sync.Mutex
+	repositories map[string]*client.Repository
+	errors       map[Operation]error
+	calls        map[Operation]int
+	// Now returns the time used for 'created_at' and 'updated_at', defaults to time.Now.
+	Now func() time.Time
+}
+
+func New() *Coveralls {
+	return &Coveralls{
+		repositories: map[string]*client.Repository{},
+		errors:       map[Operation]error{},
+		calls:        map[Operation]int{},
+		Now:          time.Now,
+	}
+}
+
+// AddRepository stores a copy of the repository as if it had been created, generating a token and timestamps if they
+// aren't set. The stored copy is returned.
+func (f *Coveralls) AddRepository(repository *client.Repository) *client.Repository {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored := copyRepository(repository)
+	f.initialize(stored)
+
+	f.repositories[key(stored.Service, stored.Name)] = stored
+	return copyRepository(stored)
+}
+
+// Repository returns a copy of the stored repository.
+func (f *Coveralls) Repository(service, name string) (*client.Repository, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	repository, ok := f.repositories[key(service, name)]
+	if !ok {
+		return nil, false
+	}
+
+	return copyRepository(repository), true
+}
+
+// DeleteRepository removes the repository, eg: to simulate it being deleted outside of Terraform.
+func (f *Coveralls) DeleteRepository(service, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.repositories, key(service, name))
+}
+
+// SetError causes every call to the operation to fail with err until it is cleared by passing nil.
+func (f *Coveralls) SetError(operation Operation, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.errors, operation)
+		return
+	}
+
+	f.errors[operation] = err
+}
+
+// Calls returns the number of times the operation has been called.
+func (f *Coveralls) Calls(operation Operation) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls[operation]
+}
+
+func (f *Coveralls) Create(_ context.Context, create *client.RepositoryCreate) (*client.Repository, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call(OperationCreate); err != nil {
+		return nil, err
+	}
+
+	if create.Service == "" || create.Name == "" {
+		return nil, apiError(http.StatusUnprocessableEntity, "service and name are required")
+	}
+
+	k := key(create.Service, create.Name)
+	if _, ok := f.repositories[k]; ok {
+		return nil, apiError(http.StatusUnprocessableEntity, "repository already exists")
+	}
+
+	// like the real api, settings that aren't sent are enabled
+	stored := &client.Repository{
+		Service:               create.Service,
+		Name:                  create.Name,
+		CommentOnPullRequests: true,
+		SendBuildStatus:       true,
+	}
+	create.Apply(stored)
+	f.initialize(stored)
+
+	f.repositories[k] = stored
+
+	// like the real api, the token isn't returned on creation
+	return withoutToken(stored), nil
+}
+
+func (f *Coveralls) Delete(_ context.Context, service, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call(OperationDelete); err != nil {
+		return err
+	}
+
+	k := key(service, name)
+	if _, ok := f.repositories[k]; !ok {
+		return apiError(http.StatusNotFound)
+	}
+
+	delete(f.repositories, k)
+	return nil
+}
+
+func (f *Coveralls) Get(_ context.Context, service, name string) (*client.Repository, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call(OperationGet); err != nil {
+		return nil, err
+	}
+
+	repository, ok := f.repositories[key(service, name)]
+	if !ok {
+		return nil, apiError(http.StatusNotFound)
+	}
+
+	return copyRepository(repository), nil
+}
+
+func (f *Coveralls) List(_ context.Context, service, owner string) ([]*client.Repository, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call(OperationList); err != nil {
+		return nil, err
+	}
+
+	repositories := []*client.Repository{}
+	for _, repository := range f.repositories {
+		if repository.Service == service && strings.EqualFold(client.Owner(repository.Name), owner) {
+			repositories = append(repositories, copyRepository(repository))
+		}
+	}
+
+	slices.SortFunc(repositories, func(a, b *client.Repository) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return repositories, nil
+}
+
+func (f *Coveralls) Update(_ context.Context, service, name string, update *client.RepositoryUpdate) (*client.Repository, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call(OperationUpdate); err != nil {
+		return nil, err
+	}
+
+	stored, ok := f.repositories[key(service, name)]
+	if !ok {
+		return nil, apiError(http.StatusNotFound)
+	}
+
+	// like the real api, settings that aren't in the update are left unchanged
+	update.Apply(stored)
+	stored.UpdatedAt = f.timestamp()
+
+	// like the real api, the token isn't returned on update
+	return withoutToken(stored), nil
+}
+
+func (f *Coveralls) ValidateCredentials(_ context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.call(OperationValidate)
+}
+
+// call records the call and returns any injected error, the lock must be held.
+func (f *Coveralls) call(operation Operation) error {
+	f.calls[operation]++
+	return f.errors[operation]
+}
+
+// initialize populates the token and timestamps, the lock must be held.
+func (f *Coveralls) initialize(repository *client.Repository) {
+	if repository.Token == "" {
+		repository.Token = newToken()
+	}
+
+	if repository.CreatedAt == "" {
+		repository.CreatedAt = f.timestamp()
+	}
+
+	if repository.UpdatedAt == "" {
+		repository.UpdatedAt = repository.CreatedAt
+	}
+}
+
+func (f *Coveralls) timestamp() string {
+	return f.Now().UTC().Format(time.RFC3339)
+}
+
+func apiError(statusCode int, messages ...string) error {
+	return &client.APIError{StatusCode: statusCode, Messages: messages}
+}
+
+// key identifies a repository, like the real api names are case-insensitive.
+func key(service, name string) string {
+	return service + "/" + strings.ToLower(name)
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func withoutToken(repository *client.Repository) *client.Repository {
+	result := copyRepository(repository)
+	result.Token = ""
+
+	return result
+}
+
+func copyRepository(repository *client.Repository) *client.Repository {
+	result := *repository
+	result.FailThreshold = copyFloat(repository.FailThreshold)
+	result.FailChangeThreshold = copyFloat(repository.FailChangeThreshold)
+
+	return &result
+}
+
+func copyFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+
+	v := *f
+	return &v
+}
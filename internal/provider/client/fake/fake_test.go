@@ -0,0 +1,122 @@
+package fake
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+)
+
+func TestCoveralls(t *testing.T) {
+	ctx := t.Context()
+	fake := New()
+
+	threshold := 80.0
+	created, err := fake.Create(ctx, &client.RepositoryCreate{
+		Service:          "github",
+		Name:             "owner/repo",
+		RepositoryUpdate: client.RepositoryUpdate{FailThreshold: client.NewField(&threshold)},
+	})
+
+	require.NoError(t, err)
+	require.Empty(t, created.Token)
+	require.NotEmpty(t, created.CreatedAt)
+
+	_, err = fake.Create(ctx, &client.RepositoryCreate{Service: "github", Name: "owner/repo"})
+	require.ErrorIs(t, err, client.ErrValidation)
+
+	got, err := fake.Get(ctx, "github", "Owner/Repo")
+	require.NoError(t, err)
+	require.Equal(t, "owner/repo", got.Name)
+	require.NotEmpty(t, got.Token)
+	require.Equal(t, 80.0, *got.FailThreshold)
+	// settings that weren't sent are enabled
+	require.True(t, got.CommentOnPullRequests)
+	require.True(t, got.SendBuildStatus)
+
+	// returned values are copies
+	*got.FailThreshold = 10.0
+	stored, _ := fake.Repository("github", "owner/repo")
+	require.Equal(t, 80.0, *stored.FailThreshold)
+
+	// settings that aren't in the update are unchanged
+	updated, err := fake.Update(ctx, "github", "owner/repo", &client.RepositoryUpdate{SendBuildStatus: client.FieldOf(true)})
+	require.NoError(t, err)
+	require.True(t, updated.SendBuildStatus)
+	require.Equal(t, 80.0, *updated.FailThreshold)
+
+	updated, err = fake.Update(ctx, "github", "owner/repo", &client.RepositoryUpdate{FailThreshold: client.NewField[float64](nil)})
+	require.NoError(t, err)
+	require.True(t, updated.SendBuildStatus)
+	require.Nil(t, updated.FailThreshold)
+
+	fake.AddRepository(&client.Repository{Service: "github", Name: "owner/other"})
+	fake.AddRepository(&client.Repository{Service: "github", Name: "owner/nested/repo"})
+	fake.AddRepository(&client.Repository{Service: "gitlab", Name: "owner/repo"})
+
+	listed, err := fake.List(ctx, "github", "owner")
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	require.Equal(t, "owner/other", listed[0].Name)
+	require.Equal(t, "owner/repo", listed[1].Name)
+	require.NotEmpty(t, listed[1].Token)
+
+	require.NoError(t, fake.Delete(ctx, "github", "owner/other"))
+	require.ErrorIs(t, fake.Delete(ctx, "github", "owner/other"), client.ErrNotFound)
+
+	fake.DeleteRepository("github", "owner/repo")
+
+	_, err = fake.Get(ctx, "github", "owner/repo")
+	require.ErrorIs(t, err, client.ErrNotFound)
+
+	_, err = fake.Update(ctx, "github", "owner/repo", &client.RepositoryUpdate{})
+	require.ErrorIs(t, err, client.ErrNotFound)
+
+	require.Equal(t, 2, fake.Calls(OperationCreate))
+	require.Equal(t, 2, fake.Calls(OperationGet))
+	require.Equal(t, 3, fake.Calls(OperationUpdate))
+	require.Equal(t, 1, fake.Calls(OperationList))
+	require.Equal(t, 2, fake.Calls(OperationDelete))
+}
+
+func TestCoverallsSetError(t *testing.T) {
+	ctx := t.Context()
+	fake := New()
+	fake.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	fake.SetError(OperationGet, &client.APIError{StatusCode: 429})
+	fake.SetError(OperationValidate, errors.New("offline"))
+
+	_, err := fake.Get(ctx, "github", "owner/repo")
+	require.ErrorIs(t, err, client.ErrRateLimited)
+	require.EqualError(t, fake.ValidateCredentials(ctx), "offline")
+
+	fake.SetError(OperationGet, nil)
+
+	_, err = fake.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+}
+
+func TestCoverallsConcurrent(t *testing.T) {
+	ctx := t.Context()
+	fake := New()
+	fake.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			_, err := fake.Get(ctx, "github", "owner/repo")
+			require.NoError(t, err)
+
+			_, err = fake.Update(ctx, "github", "owner/repo", &client.RepositoryUpdate{CommentOnPullRequests: client.FieldOf(true)})
+			require.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	require.Equal(t, 10, fake.Calls(OperationGet))
+	require.Equal(t, 10, fake.Calls(OperationUpdate))
+}
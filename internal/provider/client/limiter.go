@@ -0,0 +1,90 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit limits the rate of requests sent to the Coveralls api using a token bucket that allows bursts of up
+// to burst requests. The limit is shared by everything using the client and applies to each retry attempt.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(client *Client) error {
+		if requestsPerSecond <= 0 {
+			return errors.New("requests per second must be greater than zero")
+		}
+
+		if burst <= 0 {
+			burst = int(math.Max(1, math.Ceil(requestsPerSecond)))
+		}
+
+		limiter := rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+
+		client.transports = append(client.transports, func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if err := limiter.Wait(req.Context()); err != nil {
+					return nil, err
+				}
+				return next.RoundTrip(req)
+			})
+		})
+
+		return nil
+	}
+}
+
+// WithMaxInFlight caps the number of concurrent requests sent to the Coveralls api, additional requests block until
+// a slot becomes available or their context is done.
+func WithMaxInFlight(maxInFlight int) Option {
+	return func(client *Client) error {
+		if maxInFlight <= 0 {
+			return errors.New("max in flight requests must be greater than zero")
+		}
+
+		slots := make(chan struct{}, maxInFlight)
+
+		client.transports = append(client.transports, func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				select {
+				case slots <- struct{}{}:
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+
+				release := sync.OnceFunc(func() { <-slots })
+
+				response, err := next.RoundTrip(req)
+				if err != nil {
+					release()
+					return nil, err
+				}
+
+				// the request is in flight until its body has been consumed
+				response.Body = &releaseBody{ReadCloser: response.Body, release: release}
+				return response, nil
+			})
+		})
+
+		return nil
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := limiterServer(t, func() { calls.Add(1) })
+
+	client, err := NewCoveralls(server.URL, "fake-token", WithRateLimit(0.001, 1))
+	require.NoError(t, err)
+
+	_, err = client.Get(t.Context(), "github", "username/reponame")
+	require.NoError(t, err)
+
+	// the bucket is now empty and won't refill before the deadline
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.Get(ctx, "github", "username/reponame")
+	require.Error(t, err)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestRateLimitInvalid(t *testing.T) {
+	_, err := NewCoveralls("https://coveralls.io", "fake-token", WithRateLimit(0, 1))
+	require.Error(t, err)
+}
+
+func TestMaxInFlight(t *testing.T) {
+	var current, peak atomic.Int32
+	server := limiterServer(t, func() {
+		n := current.Add(1)
+		defer current.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	})
+
+	client, err := NewCoveralls(server.URL, "fake-token", WithMaxInFlight(2))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Go(func() {
+			_, err := client.Get(t.Context(), "github", "username/reponame")
+			require.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestMaxInFlightInvalid(t *testing.T) {
+	_, err := NewCoveralls("https://coveralls.io", "fake-token", WithMaxInFlight(0))
+	require.Error(t, err)
+}
+
+func limiterServer(t *testing.T, onRequest func()) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		onRequest()
+		w.Header().Set("Content-Type", ContentType)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
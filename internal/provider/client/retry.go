@@ -0,0 +1,143 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryMaxWait = 30 * time.Second
+
+	retryWaitTime = time.Second
+)
+
+// WithRetries enables retrying of throttled (429) and server error (5xx) responses using exponential backoff with
+// jitter, honouring any 'Retry-After' header sent by the server. Non-idempotent requests (POST) are only retried when
+// the server could not have processed them.
+func WithRetries(maxRetries int, maxWait time.Duration) Option {
+	return func(client *Client) error {
+		if maxRetries < 0 {
+			return errors.New("max retries must not be negative")
+		}
+
+		if maxWait <= 0 {
+			return errors.New("retry max wait must be greater than zero")
+		}
+
+		client.resty.
+			SetRetryCount(maxRetries).
+			SetRetryWaitTime(min(retryWaitTime, maxWait)).
+			SetRetryMaxWaitTime(maxWait).
+			SetRetryAfter(retryAfter)
+
+		// replace, rather than append to, any previously configured condition
+		client.resty.RetryConditions = []resty.RetryConditionFunc{retryCondition}
+		client.resty.RetryHooks = []resty.OnRetryFunc{retryHook}
+
+		return nil
+	}
+}
+
+func retryCondition(response *resty.Response, err error) bool {
+	// the request never made it out of resty (eg: middleware failure), nothing to retry
+	if response == nil || response.Request == nil {
+		return false
+	}
+
+	ctx := response.Request.Context()
+	if ctx.Err() != nil {
+		return false
+	}
+
+	idempotent := isIdempotent(response.Request.Method)
+
+	if err != nil {
+		// a failed dial means the request was never sent, so even a POST is safe to retry
+		return idempotent || isDialError(err)
+	}
+
+	switch response.StatusCode() {
+	case http.StatusTooManyRequests:
+		// throttled requests are rejected before being processed
+		return true
+	case http.StatusServiceUnavailable:
+		// only safe for a POST when the server explicitly asks for the request to be retried
+		return idempotent || response.Header().Get("Retry-After") != ""
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+
+	return false
+}
+
+func retryAfter(_ *resty.Client, response *resty.Response) (time.Duration, error) {
+	if response == nil || response.RawResponse == nil {
+		return 0, nil
+	}
+
+	return parseRetryAfter(response.Header().Get("Retry-After"), time.Now()), nil
+}
+
+func retryHook(response *resty.Response, err error) {
+	if response == nil || response.Request == nil {
+		return
+	}
+
+	ctx := tflog.SetField(response.Request.Context(), "method", response.Request.Method)
+	ctx = tflog.SetField(ctx, "url", response.Request.URL)
+	ctx = tflog.SetField(ctx, "attempt", response.Request.Attempt)
+
+	if err != nil {
+		ctx = tflog.SetField(ctx, "error", err.Error())
+	} else {
+		ctx = tflog.SetField(ctx, "status_code", response.StatusCode())
+	}
+
+	tflog.Debug(ctx, "Retrying coveralls request")
+}
+
+// parseRetryAfter returns the wait time from a 'Retry-After' header, which is either a number of seconds or an http
+// date. Zero is returned if the header is absent or invalid, causing the default backoff to be used.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryGetServerError(t *testing.T) {
+	client := setup(t)
+
+	want := &Repository{
+		Service: "github",
+		Name:    "username/reponame",
+		Token:   "token",
+	}
+
+	url := "https://coveralls.io/api/repos/github/username/reponame"
+	httpmock.RegisterResponder("GET", url,
+		getResponder(t, 503, map[string]string{}).Then(getResponder(t, 200, want)))
+
+	got, err := client.Get(t.Context(), "github", "username/reponame")
+
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	require.Equal(t, 2, httpmock.GetCallCountInfo()["GET "+url])
+}
+
+func TestRetryExhausted(t *testing.T) {
+	client := setup(t, WithRetries(2, time.Millisecond))
+
+	url := "https://coveralls.io/api/repos/github/username/reponame"
+	httpmock.RegisterResponder("GET", url, getResponder(t, 502, map[string]string{}))
+
+	_, err := client.Get(t.Context(), "github", "username/reponame")
+
+	require.Error(t, err)
+	require.Equal(t, 3, httpmock.GetCallCountInfo()["GET "+url])
+}
+
+func TestRetryDisabled(t *testing.T) {
+	client := setup(t, WithRetries(0, time.Millisecond))
+
+	url := "https://coveralls.io/api/repos/github/username/reponame"
+	httpmock.RegisterResponder("GET", url, getResponder(t, 503, map[string]string{}))
+
+	_, err := client.Get(t.Context(), "github", "username/reponame")
+
+	require.Error(t, err)
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+url])
+}
+
+func TestRetryPostServerError(t *testing.T) {
+	client := setup(t)
+
+	url := "https://coveralls.io/api/repos"
+	httpmock.RegisterResponder("POST", url, postResponder(t, 500, map[string]string{}))
+
+	_, err := client.Create(t.Context(), &RepositoryCreate{Service: "github", Name: "username/reponame"})
+
+	require.Error(t, err)
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["POST "+url])
+}
+
+func TestRetryPostTooManyRequests(t *testing.T) {
+	client := setup(t)
+
+	want := &Repository{
+		Service: "github",
+		Name:    "username/reponame",
+	}
+
+	url := "https://coveralls.io/api/repos"
+	throttled := postResponder(t, 429, map[string]string{}).HeaderSet(http.Header{"Retry-After": {"1"}})
+	httpmock.RegisterResponder("POST", url,
+		throttled.Then(throttled).Then(postResponder(t, 201, map[string]*Repository{"repo": want})))
+
+	got, err := client.Create(t.Context(), &RepositoryCreate{Service: want.Service, Name: want.Name})
+
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	require.Equal(t, 3, httpmock.GetCallCountInfo()["POST "+url])
+}
+
+func TestRetryCondition(t *testing.T) {
+	require.False(t, retryCondition(nil, nil))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]time.Duration{
+		"":                              0,
+		"garbage":                       0,
+		"-1":                            0,
+		"0":                             0,
+		"5":                             5 * time.Second,
+		" 120 ":                         2 * time.Minute,
+		"Thu, 01 Jan 2026 12:00:30 GMT": 30 * time.Second,
+		"Thu, 01 Jan 2026 11:59:00 GMT": 0,
+	}
+
+	for value, want := range tests {
+		t.Run(value, func(t *testing.T) {
+			require.Equal(t, want, parseRetryAfter(value, now))
+		})
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	require.True(t, isIdempotent(http.MethodGet))
+	require.True(t, isIdempotent(http.MethodPut))
+	require.False(t, isIdempotent(http.MethodPost))
+	require.False(t, isIdempotent(http.MethodPatch))
+}
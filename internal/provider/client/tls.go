@@ -0,0 +1,94 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSConfig holds the tls settings used to connect to the Coveralls api. Certificates and keys may be provided either
+// as PEM encoded content or as a path to a file containing it.
+type TLSConfig struct {
+	// CABundle contains additional certificate authorities to trust on top of the system roots.
+	CABundle string
+	// ClientCertificate and ClientKey are presented to the server for mutual tls.
+	ClientCertificate string
+	ClientKey         string
+	// InsecureSkipVerify disables verification of the server certificate chain and host name.
+	InsecureSkipVerify bool
+}
+
+// WithTLS applies the tls settings to the transport used by the client.
+func WithTLS(config TLSConfig) Option {
+	return func(client *Client) error {
+		tlsConfig, err := config.build()
+		if err != nil {
+			return err
+		}
+
+		client.transport.TLSClientConfig = tlsConfig
+		return nil
+	}
+}
+
+func (c TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CABundle != "" {
+		bundle, err := readPEM(c.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ca bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, errors.New("ca bundle does not contain any valid PEM encoded certificates")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if (c.ClientCertificate == "") != (c.ClientKey == "") {
+		return nil, errors.New("client certificate and client key must be provided together")
+	}
+
+	if c.ClientCertificate != "" {
+		cert, err := readPEM(c.ClientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client certificate: %w", err)
+		}
+
+		key, err := readPEM(c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client key: %w", err)
+		}
+
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate or key: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	return tlsConfig, nil
+}
+
+// readPEM returns value if it contains PEM encoded content, otherwise it is treated as a path to a file.
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+
+	return os.ReadFile(value)
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// TraceSubsystem is the tflog subsystem http requests and responses are logged to, its level can be set
+	// independently using the TF_LOG_PROVIDER_COVERALLS_HTTP environment variable.
+	TraceSubsystem = "coveralls_http"
+
+	traceHeaderPrefix = "http_header_"
+)
+
+var (
+	// headers that must never be logged
+	traceMaskedHeaders = []string{
+		traceHeaderPrefix + "authorization",
+		traceHeaderPrefix + "proxy_authorization",
+		traceHeaderPrefix + "cookie",
+		traceHeaderPrefix + "set_cookie",
+	}
+
+	// repository tokens returned in response bodies, eg: '"token": "abc123"'
+	traceTokenRegex = regexp.MustCompile(`"(?:repo_)?token"\s*:\s*"[^"]*"`)
+)
+
+type traceTransport struct {
+	next  http.RoundTripper
+	token string
+}
+
+func newTraceTransport(next http.RoundTripper, token string) http.RoundTripper {
+	return &traceTransport{next: next, token: token}
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := tflog.NewSubsystem(req.Context(), TraceSubsystem,
+		tflog.WithLevelFromEnv("TF_LOG_PROVIDER_COVERALLS_HTTP"),
+		tflog.WithRootFields(),
+	)
+	ctx = tflog.SubsystemMaskFieldValuesWithFieldKeys(ctx, TraceSubsystem, traceMaskedHeaders...)
+	ctx = tflog.SubsystemMaskAllFieldValuesRegexes(ctx, TraceSubsystem, traceTokenRegex)
+	if t.token != "" {
+		ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, TraceSubsystem, t.token)
+		ctx = tflog.SubsystemMaskMessageStrings(ctx, TraceSubsystem, t.token)
+	}
+
+	ctx = tflog.SubsystemSetField(ctx, TraceSubsystem, "http_method", req.Method)
+	ctx = tflog.SubsystemSetField(ctx, TraceSubsystem, "http_url", req.URL.Redacted())
+
+	fields := traceHeaders(req.Header)
+	if body := traceRequestBody(req); body != "" {
+		fields["http_request_body"] = body
+	}
+	tflog.SubsystemTrace(ctx, TraceSubsystem, "Sending HTTP request", fields)
+
+	start := time.Now()
+	response, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	ctx = tflog.SubsystemSetField(ctx, TraceSubsystem, "http_latency_ms", latency.Milliseconds())
+
+	if err != nil {
+		tflog.SubsystemDebug(ctx, TraceSubsystem, "HTTP request failed", map[string]any{"error": err.Error()})
+		return nil, err
+	}
+
+	fields = traceHeaders(response.Header)
+	fields["http_status_code"] = response.StatusCode
+
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		tflog.SubsystemDebug(ctx, TraceSubsystem, "Unable to read HTTP response", map[string]any{"error": err.Error()})
+		return nil, err
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > 0 {
+		fields["http_response_body"] = string(body)
+	}
+
+	tflog.SubsystemTrace(ctx, TraceSubsystem, "Received HTTP response", fields)
+
+	return response, nil
+}
+
+func traceHeaders(header http.Header) map[string]any {
+	fields := make(map[string]any, len(header))
+	for key, values := range header {
+		name := traceHeaderPrefix + strings.ReplaceAll(strings.ToLower(key), "-", "_")
+		fields[name] = strings.Join(values, ", ")
+	}
+
+	return fields
+}
+
+func traceRequestBody(req *http.Request) string {
+	if req.Body == nil || req.GetBody == nil {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
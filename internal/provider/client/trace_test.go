@@ -0,0 +1,59 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		w.Header().Set("X-Request-Id", "abc-123")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"repo": {"name": "username/reponame", "token": "repo-secret"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	client, err := NewCoveralls(server.URL, "api-secret")
+	require.NoError(t, err)
+
+	_, err = client.Create(ctx, &RepositoryCreate{Service: "github", Name: "username/reponame"})
+	require.NoError(t, err)
+
+	require.NotContains(t, output.String(), "secret")
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	require.NoError(t, err)
+
+	var traced []map[string]any
+	for _, entry := range entries {
+		if entry["@module"] == "provider."+TraceSubsystem {
+			traced = append(traced, entry)
+		}
+	}
+
+	require.Len(t, traced, 2)
+
+	request := traced[0]
+	require.Equal(t, "Sending HTTP request", request["@message"])
+	require.Equal(t, "POST", request["http_method"])
+	require.Equal(t, server.URL+"/api/repos", request["http_url"])
+	require.Equal(t, "***", request["http_header_authorization"])
+	require.Contains(t, request["http_request_body"], `"name":"username/reponame"`)
+
+	response := traced[1]
+	require.Equal(t, "Received HTTP response", response["@message"])
+	require.Equal(t, float64(http.StatusCreated), response["http_status_code"])
+	require.Equal(t, "abc-123", response["http_header_x_request_id"])
+	require.Contains(t, response, "http_latency_ms")
+	require.Equal(t, `{"repo": {"name": "username/reponame", ***}}`, response["http_response_body"])
+}
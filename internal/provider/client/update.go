@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/json"
+)
+
+// Field is a setting of a RepositoryUpdate. Only fields that are set are sent, so that settings changed by others, eg:
+// in the Coveralls UI, aren't overwritten. A field set to nil is sent as null, eg: to remove a threshold.
+type Field[T any] struct {
+	value *T
+	set   bool
+}
+
+// NewField returns a field that is set to the value, or null if it is nil.
+func NewField[T any](value *T) Field[T] {
+	if value == nil {
+		return Field[T]{set: true}
+	}
+
+	v := *value
+	return Field[T]{value: &v, set: true}
+}
+
+// FieldOf returns a field that is set to the value.
+func FieldOf[T any](value T) Field[T] {
+	return NewField(&value)
+}
+
+// Get returns the value and true if the field is set.
+func (f Field[T]) Get() (*T, bool) {
+	if f.value == nil {
+		return nil, f.set
+	}
+
+	v := *f.value
+	return &v, f.set
+}
+
+// IsZero returns true if the field isn't set, omitting it from the request.
+func (f Field[T]) IsZero() bool {
+	return !f.set
+}
+
+func (f Field[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.value)
+}
+
+// UnmarshalJSON is only called for fields that are present, null included.
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.set = true
+	f.value = nil
+
+	return json.Unmarshal(data, &f.value)
+}
+
+// RepositoryUpdate holds the settings changed by Update, settings that aren't set are left unchanged.
+type RepositoryUpdate struct {
+	CommentOnPullRequests Field[bool]    `json:"comment_on_pull_requests,omitzero"`
+	SendBuildStatus       Field[bool]    `json:"send_build_status,omitzero"`
+	FailThreshold         Field[float64] `json:"commit_status_fail_threshold,omitzero"`
+	FailChangeThreshold   Field[float64] `json:"commit_status_fail_change_threshold,omitzero"`
+}
+
+type updateBody struct {
+	Repo *RepositoryUpdate `json:"repo"`
+}
+
+// NewRepositoryUpdate returns an update that sets every setting to that of the repository.
+func NewRepositoryUpdate(repository *Repository) *RepositoryUpdate {
+	return &RepositoryUpdate{
+		CommentOnPullRequests: NewField(&repository.CommentOnPullRequests),
+		SendBuildStatus:       NewField(&repository.SendBuildStatus),
+		FailThreshold:         NewField(repository.FailThreshold),
+		FailChangeThreshold:   NewField(repository.FailChangeThreshold),
+	}
+}
+
+// IsEmpty returns true if no settings are set.
+func (u *RepositoryUpdate) IsEmpty() bool {
+	return u.CommentOnPullRequests.IsZero() && u.SendBuildStatus.IsZero() && u.FailThreshold.IsZero() &&
+		u.FailChangeThreshold.IsZero()
+}
+
+// Apply changes the settings of the repository that are set, eg: to determine the result of an update.
+func (u *RepositoryUpdate) Apply(repository *Repository) {
+	if value, ok := u.CommentOnPullRequests.Get(); ok {
+		repository.CommentOnPullRequests = value != nil && *value
+	}
+
+	if value, ok := u.SendBuildStatus.Get(); ok {
+		repository.SendBuildStatus = value != nil && *value
+	}
+
+	if value, ok := u.FailThreshold.Get(); ok {
+		repository.FailThreshold = value
+	}
+
+	if value, ok := u.FailChangeThreshold.Get(); ok {
+		repository.FailChangeThreshold = value
+	}
+}
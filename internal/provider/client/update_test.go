@@ -0,0 +1,58 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestField(t *testing.T) {
+	var unset Field[bool]
+	value, ok := unset.Get()
+	require.False(t, ok)
+	require.Nil(t, value)
+	require.True(t, unset.IsZero())
+
+	value, ok = FieldOf(false).Get()
+	require.True(t, ok)
+	require.False(t, *value)
+
+	threshold := 80.0
+	field := NewField(&threshold)
+	threshold = 10.0
+
+	// the field holds a copy of the value
+	got, ok := field.Get()
+	require.True(t, ok)
+	require.Equal(t, 80.0, *got)
+
+	got, ok = NewField[float64](nil).Get()
+	require.True(t, ok)
+	require.Nil(t, got)
+}
+
+func TestRepositoryUpdateApply(t *testing.T) {
+	threshold := 80.0
+	repository := &Repository{
+		CommentOnPullRequests: true,
+		FailThreshold:         &threshold,
+		FailChangeThreshold:   &threshold,
+	}
+
+	update := &RepositoryUpdate{
+		SendBuildStatus: FieldOf(true),
+		FailThreshold:   NewField[float64](nil),
+	}
+	require.False(t, update.IsEmpty())
+
+	update.Apply(repository)
+
+	require.Equal(t, &Repository{
+		CommentOnPullRequests: true,
+		SendBuildStatus:       true,
+		FailChangeThreshold:   &threshold,
+	}, repository)
+
+	require.True(t, (&RepositoryUpdate{}).IsEmpty())
+	require.False(t, NewRepositoryUpdate(&Repository{}).IsEmpty())
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"terraform-provider-coveralls/internal/provider/client"
+)
+
+var (
+	// errInconsistent is returned when the api doesn't reflect a write before the context is done.
+	errInconsistent = errors.New("repository did not become consistent")
+
+	// the interval between reads doubles after each inconsistent result, up to the maximum
+	consistencyMinInterval = time.Second
+	consistencyMaxInterval = 10 * time.Second
+)
+
+// waitForConsistency reads the repository until it has a token and the settings that were written, or ctx is done.
+// Only the settings that are set in want are compared, the others may have been changed by others.
+// A repository that isn't found yet is polled for, as it may have only just been created. Once ctx is done an error
+// matching errInconsistent is returned describing the last difference seen.
+func waitForConsistency(ctx context.Context, api client.API, service, name string, want *client.RepositoryUpdate) (*client.Repository, error) {
+	interval := consistencyMinInterval
+	reason := ""
+
+	for {
+		repository, err := api.Get(ctx, service, name)
+
+		switch {
+		case errors.Is(err, client.ErrNotFound):
+			reason = "the repository was not found"
+		case err != nil && reason != "" && ctx.Err() != nil:
+			// the deadline expired during the read, report why the previous one wasn't accepted
+			return nil, fmt.Errorf("%w: %s", errInconsistent, reason)
+		case err != nil:
+			return nil, err
+		default:
+			reason = inconsistency(repository, want)
+			if reason == "" {
+				return repository, nil
+			}
+		}
+
+		tflog.Debug(ctx, "Waiting for repository to become consistent", map[string]any{
+			"reason":   reason,
+			"interval": interval.String(),
+		})
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %s", errInconsistent, reason)
+		case <-timer.C:
+		}
+
+		interval = min(interval*2, consistencyMaxInterval)
+	}
+}
+
+// inconsistency describes the first difference between the repository read and the settings written, or returns an
+// empty string if there is none.
+func inconsistency(got *client.Repository, written *client.RepositoryUpdate) string {
+	// settings that weren't written are expected to be whatever was read
+	want := *got
+	written.Apply(&want)
+
+	switch {
+	case got.Token == "":
+		return "the repository token is empty"
+	case got.CommentOnPullRequests != want.CommentOnPullRequests:
+		return fmt.Sprintf("comment_on_pull_requests is %t, expected %t", got.CommentOnPullRequests, want.CommentOnPullRequests)
+	case got.SendBuildStatus != want.SendBuildStatus:
+		return fmt.Sprintf("send_build_status is %t, expected %t", got.SendBuildStatus, want.SendBuildStatus)
+	case !equalThreshold(got.FailThreshold, want.FailThreshold):
+		return fmt.Sprintf("commit_status_fail_threshold is %s, expected %s",
+			formatThreshold(got.FailThreshold), formatThreshold(want.FailThreshold))
+	case !equalThreshold(got.FailChangeThreshold, want.FailChangeThreshold):
+		return fmt.Sprintf("commit_status_fail_change_threshold is %s, expected %s",
+			formatThreshold(got.FailChangeThreshold), formatThreshold(want.FailChangeThreshold))
+	default:
+		return ""
+	}
+}
+
+func equalThreshold(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func formatThreshold(threshold *float64) string {
+	if threshold == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%g", *threshold)
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+	"terraform-provider-coveralls/internal/provider/client/fake"
+)
+
+// staleAPI returns the given stale responses to the first reads, before those of the wrapped fake.
+type staleAPI struct {
+	*fake.Coveralls
+	stale []*client.Repository
+	gets  int
+}
+
+func (s *staleAPI) Get(ctx context.Context, service, name string) (*client.Repository, error) {
+	s.gets++
+	if len(s.stale) > 0 {
+		repository := s.stale[0]
+		s.stale = s.stale[1:]
+		return repository, nil
+	}
+	return s.Coveralls.Get(ctx, service, name)
+}
+
+func testConsistencyIntervals(t *testing.T) {
+	minInterval, maxInterval := consistencyMinInterval, consistencyMaxInterval
+	consistencyMinInterval, consistencyMaxInterval = time.Millisecond, 5*time.Millisecond
+
+	t.Cleanup(func() {
+		consistencyMinInterval, consistencyMaxInterval = minInterval, maxInterval
+	})
+}
+
+func TestWaitForConsistency(t *testing.T) {
+	testConsistencyIntervals(t)
+
+	api := &staleAPI{
+		Coveralls: fake.New(),
+		stale: []*client.Repository{
+			{Service: "github", Name: "owner/repo"},
+			{Service: "github", Name: "owner/repo", Token: "abc", SendBuildStatus: false},
+		},
+	}
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo", SendBuildStatus: true})
+
+	repository, err := waitForConsistency(t.Context(), api, "github", "owner/repo", &client.RepositoryUpdate{SendBuildStatus: client.FieldOf(true)})
+
+	require.NoError(t, err)
+	require.True(t, repository.SendBuildStatus)
+	require.NotEmpty(t, repository.Token)
+	require.Equal(t, 3, api.gets)
+}
+
+func TestWaitForConsistencyNotFound(t *testing.T) {
+	testConsistencyIntervals(t)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := waitForConsistency(ctx, fake.New(), "github", "owner/repo", &client.RepositoryUpdate{})
+
+	require.ErrorIs(t, err, errInconsistent)
+	require.ErrorContains(t, err, "the repository was not found")
+}
+
+func TestWaitForConsistencyTimeout(t *testing.T) {
+	testConsistencyIntervals(t)
+
+	api := fake.New()
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	threshold := 80.0
+	_, err := waitForConsistency(ctx, api, "github", "owner/repo", &client.RepositoryUpdate{FailThreshold: client.NewField(&threshold)})
+
+	require.ErrorIs(t, err, errInconsistent)
+	require.ErrorContains(t, err, "commit_status_fail_threshold is null, expected 80")
+}
+
+func TestWaitForConsistencyError(t *testing.T) {
+	testConsistencyIntervals(t)
+
+	api := fake.New()
+	api.SetError(fake.OperationGet, errors.New("boom"))
+
+	_, err := waitForConsistency(t.Context(), api, "github", "owner/repo", &client.RepositoryUpdate{})
+
+	require.EqualError(t, err, "boom")
+	require.Equal(t, 1, api.Calls(fake.OperationGet))
+}
+
+func TestInconsistency(t *testing.T) {
+	low, high := 10.0, 20.0
+
+	require.Empty(t, inconsistency(&client.Repository{Token: "abc", FailThreshold: &low},
+		&client.RepositoryUpdate{FailThreshold: client.NewField(&low)}))
+	require.Equal(t, "the repository token is empty", inconsistency(&client.Repository{}, &client.RepositoryUpdate{}))
+	require.Equal(t, "comment_on_pull_requests is false, expected true",
+		inconsistency(&client.Repository{Token: "abc"}, &client.RepositoryUpdate{CommentOnPullRequests: client.FieldOf(true)}))
+	require.Equal(t, "commit_status_fail_change_threshold is 10, expected 20",
+		inconsistency(&client.Repository{Token: "abc", FailChangeThreshold: &low},
+			&client.RepositoryUpdate{FailChangeThreshold: client.NewField(&high)}))
+
+	// settings that weren't written aren't compared
+	require.Empty(t, inconsistency(&client.Repository{Token: "abc", SendBuildStatus: true, FailThreshold: &low},
+		&client.RepositoryUpdate{}))
+}
+
+func TestRepositoryResourceCreateInconsistent(t *testing.T) {
+	testConsistencyIntervals(t)
+
+	ctx := t.Context()
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	// the repository is created but never becomes readable
+	api.SetError(fake.OperationGet, client.ErrNotFound)
+
+	plan := testRepositoryResourceState(t, r, &RepositoryState{
+		Service:               types.StringValue("github"),
+		Name:                  NewRepositoryNameValue("owner/repo"),
+		CommentOnPullRequests: types.BoolValue(true),
+		SendBuildStatus:       types.BoolValue(false),
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	resp := &fwresource.CreateResponse{State: tfsdk.State{Schema: plan.Schema}}
+	r.Create(ctx, fwresource.CreateRequest{Plan: tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}}, resp)
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.Equal(t, "Inconsistent repository after create", resp.Diagnostics.Errors()[0].Summary())
+	require.Contains(t, resp.Diagnostics.Errors()[0].Detail(), `"github:owner/repo"`)
+	require.True(t, resp.State.Raw.IsNull())
+}
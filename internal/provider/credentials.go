@@ -0,0 +1,227 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"terraform-provider-coveralls/internal/provider/client"
+)
+
+const (
+	tokenCommandTimeout = 30 * time.Second
+)
+
+// resolveToken returns the api token from the first available source, in order of precedence:
+//
+//  1. the 'token' attribute
+//  2. the file named by the 'token_file' attribute
+//  3. the output of the 'token_command' attribute
+//  4. the COVERALLS_API_TOKEN environment variable
+//  5. the file named by the COVERALLS_API_TOKEN_FILE environment variable
+//
+// Only one of the attributes may be configured, environment variables are only consulted if none of them are.
+//
+// The name of the source the token was read from is returned alongside it.
+func resolveToken(ctx context.Context, config *CoverallsProviderModel) (string, string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var configured []string
+	if !config.Token.IsNull() {
+		configured = append(configured, "token")
+	}
+	if !config.TokenFile.IsNull() {
+		configured = append(configured, "token_file")
+	}
+	if !config.TokenCommand.IsNull() {
+		configured = append(configured, "token_command")
+	}
+
+	if len(configured) > 1 {
+		diags.AddAttributeError(
+			path.Root(configured[1]),
+			"Conflicting API token sources",
+			fmt.Sprintf("Only one of %s may be configured.", strings.Join(configured, ", ")),
+		)
+		return "", "", diags
+	}
+
+	var (
+		token  string
+		source string
+		err    error
+	)
+
+	switch {
+	case !config.Token.IsNull():
+		token, source = config.Token.ValueString(), "token"
+	case !config.TokenFile.IsNull():
+		source = "token_file"
+		token, err = readTokenFile(config.TokenFile.ValueString())
+	case !config.TokenCommand.IsNull():
+		var command []string
+		source = "token_command"
+
+		diags.Append(config.TokenCommand.ElementsAs(ctx, &command, false)...)
+		if diags.HasError() {
+			return "", "", diags
+		}
+
+		token, err = runTokenCommand(ctx, command)
+	case os.Getenv("COVERALLS_API_TOKEN") != "":
+		token, source = os.Getenv("COVERALLS_API_TOKEN"), "COVERALLS_API_TOKEN"
+
+		if os.Getenv("COVERALLS_API_TOKEN_FILE") != "" {
+			diags.AddWarning(
+				"Multiple API token environment variables",
+				"Both COVERALLS_API_TOKEN and COVERALLS_API_TOKEN_FILE are set, COVERALLS_API_TOKEN takes precedence.",
+			)
+		}
+	case os.Getenv("COVERALLS_API_TOKEN_FILE") != "":
+		source = "COVERALLS_API_TOKEN_FILE"
+		token, err = readTokenFile(os.Getenv("COVERALLS_API_TOKEN_FILE"))
+	}
+
+	if err != nil {
+		if len(configured) == 1 {
+			diags.AddAttributeError(path.Root(source), "Unable to read API token", err.Error())
+		} else {
+			diags.AddError("Unable to read API token", fmt.Sprintf("%s: %s", source, err))
+		}
+		return "", "", diags
+	}
+
+	if token == "" {
+		diags.AddAttributeError(
+			path.Root("token"),
+			"Missing Client API token",
+			"The provider cannot create the Client client as there is a missing API Token. "+
+				"Set one of token, token_file or token_command in the configuration or use the COVERALLS_API_TOKEN "+
+				"or COVERALLS_API_TOKEN_FILE environment variables",
+		)
+		return "", "", diags
+	}
+
+	ctx = tflog.SetField(ctx, "token_source", source)
+	tflog.Debug(ctx, "Resolved coveralls api token")
+
+	return token, source, diags
+}
+
+// validatedCredentials caches the outcome of validating an endpoint and token, keyed by their hash, so that aliased
+// provider instances sharing them only validate once per process.
+var validatedCredentials sync.Map
+
+// validateCredentials checks the token is accepted by the api, returning an error matching client.ErrUnauthorized or
+// client.ErrForbidden if it isn't. Only those outcomes are cached, other failures leave the token unchecked, eg: when
+// rate limited, so a later instance can retry.
+func validateCredentials(ctx context.Context, c client.API, endpoint, token string) error {
+	key := sha256.Sum256([]byte(endpoint + "\x00" + token))
+
+	if result, ok := validatedCredentials.Load(key); ok {
+		tflog.Debug(ctx, "Using cached coveralls credentials validation")
+
+		err, _ := result.(error)
+		return err
+	}
+
+	err := c.ValidateCredentials(ctx)
+
+	if err == nil || errors.Is(err, client.ErrUnauthorized) || errors.Is(err, client.ErrForbidden) {
+		validatedCredentials.Store(key, err)
+	}
+
+	return err
+}
+
+// checkCredentials validates the token, reporting a rejected token against the attribute it was read from.
+func checkCredentials(ctx context.Context, c client.API, endpoint, token, source string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	err := validateCredentials(ctx, c, endpoint, token)
+
+	if errors.Is(err, client.ErrUnauthorized) || errors.Is(err, client.ErrForbidden) {
+		attribute := source
+		if attribute != "token_file" && attribute != "token_command" {
+			attribute = "token"
+		}
+
+		diags.AddAttributeError(
+			path.Root(attribute),
+			"Invalid Coveralls API token",
+			fmt.Sprintf("The api token read from %s was rejected by %s: %s. Check the token is correct and has not "+
+				"been revoked.", source, endpoint, err),
+		)
+	} else if errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrNotSupported) {
+		// the installation can't be asked, a rejected token fails the first request instead
+		tflog.Warn(ctx, "Unable to validate coveralls credentials, the api doesn't support the request",
+			map[string]any{"error": err.Error()})
+	} else if err != nil {
+		diags.AddError(
+			"Unable to validate Coveralls API token",
+			"The api token could not be validated, set skip_credentials_validation to skip this check: "+err.Error(),
+		)
+	}
+
+	return diags
+}
+
+func readTokenFile(name string) (string, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("token file %q is empty", name)
+	}
+
+	return token, nil
+}
+
+// runTokenCommand executes the command, without a shell, and returns the token written to its stdout.
+func runTokenCommand(ctx context.Context, command []string) (string, error) {
+	if len(command) == 0 || command[0] == "" {
+		return "", errors.New("token command must not be empty")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("token command %q timed out after %s", command[0], tokenCommandTimeout)
+		}
+
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("token command %q failed: %w: %s", command[0], err, msg)
+		}
+
+		return "", fmt.Errorf("token command %q failed: %w", command[0], err)
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("token command %q did not write a token to stdout", command[0])
+	}
+
+	return token, nil
+}
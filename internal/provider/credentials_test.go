@@ -0,0 +1,224 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+)
+
+func TestResolveToken(t *testing.T) {
+	t.Setenv("COVERALLS_API_TOKEN", "env-token")
+
+	token, _, diags := resolveToken(t.Context(), &CoverallsProviderModel{Token: types.StringValue("config-token")})
+
+	require.False(t, diags.HasError())
+	require.Equal(t, "config-token", token)
+}
+
+func TestResolveTokenFile(t *testing.T) {
+	t.Setenv("COVERALLS_API_TOKEN", "env-token")
+
+	token, _, diags := resolveToken(t.Context(), &CoverallsProviderModel{
+		TokenFile: types.StringValue(tokenFile(t, "  file-token\n")),
+	})
+
+	require.False(t, diags.HasError())
+	require.Equal(t, "file-token", token)
+}
+
+func TestResolveTokenFileInvalid(t *testing.T) {
+	for name, file := range map[string]string{
+		"missing": filepath.Join(t.TempDir(), "missing"),
+		"empty":   tokenFile(t, "\n"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, diags := resolveToken(t.Context(), &CoverallsProviderModel{TokenFile: types.StringValue(file)})
+
+			require.True(t, diags.HasError())
+			require.Equal(t, "Unable to read API token", diags.Errors()[0].Summary())
+		})
+	}
+}
+
+func TestResolveTokenCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a posix shell")
+	}
+
+	tests := map[string]struct {
+		command []string
+		token   string
+	}{
+		"success":   {[]string{"echo", "command-token"}, "command-token"},
+		"failure":   {[]string{"sh", "-c", "echo oops >&2; exit 1"}, ""},
+		"no output": {[]string{"true"}, ""},
+		"missing":   {[]string{"coveralls-token-command-that-does-not-exist"}, ""},
+		"empty":     {[]string{""}, ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			token, _, diags := resolveToken(t.Context(), &CoverallsProviderModel{
+				TokenCommand: tokenCommand(test.command...),
+			})
+
+			require.Equal(t, test.token == "", diags.HasError())
+			require.Equal(t, test.token, token)
+		})
+	}
+}
+
+func TestResolveTokenConflict(t *testing.T) {
+	_, _, diags := resolveToken(t.Context(), &CoverallsProviderModel{
+		Token:        types.StringValue("config-token"),
+		TokenCommand: tokenCommand("echo", "command-token"),
+	})
+
+	require.True(t, diags.HasError())
+	require.Equal(t, "Conflicting API token sources", diags.Errors()[0].Summary())
+}
+
+func TestResolveTokenEnvironment(t *testing.T) {
+	t.Setenv("COVERALLS_API_TOKEN", "")
+	t.Setenv("COVERALLS_API_TOKEN_FILE", tokenFile(t, "env-file-token"))
+
+	token, _, diags := resolveToken(t.Context(), &CoverallsProviderModel{})
+
+	require.False(t, diags.HasError())
+	require.Equal(t, "env-file-token", token)
+
+	t.Setenv("COVERALLS_API_TOKEN", "env-token")
+
+	token, _, diags = resolveToken(t.Context(), &CoverallsProviderModel{})
+
+	require.False(t, diags.HasError())
+	require.Len(t, diags.Warnings(), 1)
+	require.Equal(t, "env-token", token)
+}
+
+func TestResolveTokenMissing(t *testing.T) {
+	t.Setenv("COVERALLS_API_TOKEN", "")
+	t.Setenv("COVERALLS_API_TOKEN_FILE", "")
+
+	_, _, diags := resolveToken(t.Context(), &CoverallsProviderModel{})
+
+	require.True(t, diags.HasError())
+	require.Equal(t, "Missing Client API token", diags.Errors()[0].Summary())
+}
+
+func TestValidateCredentials(t *testing.T) {
+	var calls atomic.Int32
+	coveralls := testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+		calls.Add(1)
+		require.Equal(t, "/api/repos", req.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"repos": []}`))
+	})
+
+	// validation is cached per endpoint and token
+	for range 2 {
+		require.NoError(t, validateCredentials(t.Context(), coveralls.client, t.Name(), "fake-token"))
+	}
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestValidateCredentialsUnchecked(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var calls atomic.Int32
+			coveralls := testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(status)
+			})
+
+			// the token wasn't checked, so it isn't cached as valid
+			for range 2 {
+				err := validateCredentials(t.Context(), coveralls.client, t.Name(), "fake-token")
+				require.Error(t, err)
+			}
+			require.Equal(t, int32(2), calls.Load())
+		})
+	}
+}
+
+func TestCheckCredentialsRejected(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := client.NewCoveralls(server.URL, "bad-token", client.WithRetries(0, time.Millisecond))
+	require.NoError(t, err)
+
+	for range 2 {
+		diags := checkCredentials(t.Context(), c, server.URL, "bad-token", "token_file")
+
+		require.True(t, diags.HasError())
+		require.Equal(t, "Invalid Coveralls API token", diags.Errors()[0].Summary())
+		require.Equal(t, path.Root("token_file"), diags.Errors()[0].(diag.DiagnosticWithPath).Path())
+	}
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestCheckCredentialsUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := client.NewCoveralls(server.URL, "fake-token", client.WithRetries(0, time.Millisecond))
+	require.NoError(t, err)
+
+	// transient failures aren't cached
+	for range 2 {
+		diags := checkCredentials(t.Context(), c, server.URL, "fake-token", "COVERALLS_API_TOKEN")
+
+		require.True(t, diags.HasError())
+		require.Equal(t, "Unable to validate Coveralls API token", diags.Errors()[0].Summary())
+	}
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestCheckCredentialsNotSupported(t *testing.T) {
+	coveralls := testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	// an installation that can't be asked doesn't fail configuration, the first request checks the token instead
+	diags := checkCredentials(t.Context(), coveralls.client, t.Name(), "fake-token", "token")
+	require.False(t, diags.HasError())
+}
+
+func tokenFile(t *testing.T, content string) string {
+	name := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+
+	return name
+}
+
+func tokenCommand(command ...string) types.List {
+	elements := make([]attr.Value, 0, len(command))
+	for _, arg := range command {
+		elements = append(elements, types.StringValue(arg))
+	}
+
+	return types.ListValueMust(types.StringType, elements)
+}
@@ -2,10 +2,16 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"terraform-provider-coveralls/internal/provider/client"
 )
 
 var _ datasource.DataSource = &RepositoryDataSource{}
@@ -47,16 +53,25 @@ func (d *RepositoryDataSource) Schema(ctx context.Context, req datasource.Schema
 				Computed:    true,
 			},
 			"name": schema.StringAttribute{
-				MarkdownDescription: "Name of the repository in the form `<owner>/<name>`.",
+				MarkdownDescription: "Name of the repository in the form `<owner>/<name>`, compared case-insensitively.",
+				CustomType:          RepositoryNameType{},
 				Required:            true,
+				Validators: []validator.String{
+					repositoryNameValidator(),
+				},
 			},
 			"send_build_status": schema.BoolAttribute{
 				Description: "Whether build status should be sent to the git provider.",
 				Computed:    true,
 			},
 			"service": schema.StringAttribute{
-				MarkdownDescription: "Git provider, eg: `github`",
-				Required:            true,
+				MarkdownDescription: "Git provider, one of `github`, `gitlab`, `bitbucket`, `stash`, " +
+					"`github_enterprise` or `gitlab_enterprise`. Defaults to the provider `default_service`.",
+				Optional: true,
+				Computed: true,
+				Validators: []validator.String{
+					serviceValidator(),
+				},
 			},
 			"token": schema.StringAttribute{
 				Description: "Repository Token.",
@@ -92,7 +107,27 @@ func (d *RepositoryDataSource) Read(ctx context.Context, req datasource.ReadRequ
 	state := &RepositoryState{}
 	resp.Diagnostics.Append(req.Config.Get(ctx, state)...)
 
-	repository, err := d.coveralls.client.Get(ctx, state.Service.ValueString(), state.Name.ValueString())
+	service := d.coveralls.service(state.Service)
+
+	if service == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("service"),
+			"Missing service",
+			"The service must be set on the data source or using the provider default_service attribute.",
+		)
+		return
+	}
+
+	repository, err := d.coveralls.getRepository(ctx, service, state.Name.ValueString())
+
+	if errors.Is(err, client.ErrNotFound) {
+		resp.Diagnostics.AddError(
+			"Repository not found",
+			fmt.Sprintf("Repository %q was not found for service %q, check the name and service are correct and "+
+				"that the api token has access to it.", state.Name.ValueString(), service),
+		)
+		return
+	}
 
 	if err != nil {
 		ctx = tflog.SetField(ctx, "error", err.Error())
@@ -105,6 +140,8 @@ func (d *RepositoryDataSource) Read(ctx context.Context, req datasource.ReadRequ
 		return
 	}
 
-	diags := resp.State.Set(ctx, d.coveralls.converter(repository))
+	state = d.coveralls.converter(repository)
+
+	diags := resp.State.Set(ctx, state)
 	resp.Diagnostics.Append(diags...)
 }
@@ -2,30 +2,189 @@ package provider
 
 import (
 	"fmt"
+	"net/http"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+	"terraform-provider-coveralls/internal/provider/client/coverallstest"
+	"terraform-provider-coveralls/internal/provider/client/fake"
 )
 
-func TestAccExampleDataSource(t *testing.T) {
+func TestAccRepositoryDataSource(t *testing.T) {
+	server := coverallstest.NewServer(t)
+	repository := server.Fake().AddRepository(&client.Repository{
+		Service:               service,
+		Name:                  name,
+		CommentOnPullRequests: true,
+	})
+
 	resource.Test(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
 			// Read testing
 			{
-				Config: providerConfig + testAccExampleDataSourceConfig,
+				Config: testAccProviderConfig(server) + testAccRepositoryDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.coveralls_repository.test", "id", service+":"+name),
+					resource.TestCheckResourceAttr("data.coveralls_repository.test", "service", service),
+					resource.TestCheckResourceAttr("data.coveralls_repository.test", "name", name),
+					resource.TestCheckResourceAttr("data.coveralls_repository.test", "token", repository.Token),
+					resource.TestCheckResourceAttr("data.coveralls_repository.test", "comment_on_pull_requests", "true"),
+					resource.TestCheckResourceAttr("data.coveralls_repository.test", "send_build_status", "false"),
+					resource.TestCheckNoResourceAttr("data.coveralls_repository.test", "commit_status_fail_threshold"),
+				),
+			},
+			// Not found testing
+			{
+				Config: testAccProviderConfig(server) + `
+data "coveralls_repository" "test" {
+  service = "github"
+  name    = "owner/missing"
+}`,
+				ExpectError: regexp.MustCompile("Repository not found"),
+			},
+			// Validation testing
+			{
+				Config: testAccProviderConfig(server) + `
+data "coveralls_repository" "test" {
+  service = "githib"
+  name    = "owner/repo"
+}`,
+				ExpectError: regexp.MustCompile(`Invalid Attribute Value Match`),
+			},
+			{
+				Config: testAccProviderConfig(server) + `
+data "coveralls_repository" "test" {
+  service = "github"
+  name    = "repo"
+}`,
+				ExpectError: regexp.MustCompile(`must be in the form <owner>/<name>`),
+			},
+		},
+	})
+}
+
+func TestAccRepositoryDataSourceRecorded(t *testing.T) {
+	factories, providerConfig := testAccCassette(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: factories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: providerConfig + testAccRepositoryDataSourceConfig,
 				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.coveralls_repository.test", "id", service+":"+name),
 					resource.TestCheckResourceAttr("data.coveralls_repository.test", "service", service),
 					resource.TestCheckResourceAttr("data.coveralls_repository.test", "name", name),
+					resource.TestCheckResourceAttrSet("data.coveralls_repository.test", "token"),
+					resource.TestCheckResourceAttrSet("data.coveralls_repository.test", "created_at"),
 				),
 			},
 		},
 	})
 }
 
-var testAccExampleDataSourceConfig = fmt.Sprintf(`
+var testAccRepositoryDataSourceConfig = fmt.Sprintf(`
 data "coveralls_repository" "test" {
   service = "%s"
   name 	  = "%s"
 }`, service, name)
+
+func TestRepositoryDataSourceReadNotFound(t *testing.T) {
+	d := &RepositoryDataSource{
+		coveralls: testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}),
+	}
+
+	resp := testRepositoryDataSourceRead(t, d, &RepositoryState{
+		Service: types.StringValue("github"),
+		Name:    NewRepositoryNameValue("owner/repo"),
+	})
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.Equal(t, "Repository not found", resp.Diagnostics.Errors()[0].Summary())
+}
+
+func TestRepositoryDataSourceReadDefaultService(t *testing.T) {
+	d := &RepositoryDataSource{
+		coveralls: testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+			require.Equal(t, "/api/repos/gitlab/owner/repo", req.URL.Path)
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name": "owner/repo", "token": "repo-token"}`))
+		}),
+	}
+	d.coveralls.defaultService = "gitlab"
+
+	resp := testRepositoryDataSourceRead(t, d, &RepositoryState{
+		Name: NewRepositoryNameValue("owner/repo"),
+	})
+
+	require.False(t, resp.Diagnostics.HasError())
+
+	state := &RepositoryState{}
+	require.False(t, resp.State.Get(t.Context(), state).HasError())
+	require.Equal(t, "gitlab", state.Service.ValueString())
+	require.Equal(t, "gitlab:owner/repo", state.Id.ValueString())
+}
+
+func TestRepositoryDataSourceReadPrefetched(t *testing.T) {
+	coveralls, api := testFakeCoveralls()
+	coveralls.repositories = newRepositoryCache(api, time.Minute)
+	d := &RepositoryDataSource{coveralls: coveralls}
+
+	stored := api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	resp := testRepositoryDataSourceRead(t, d, &RepositoryState{
+		Service: types.StringValue("github"),
+		Name:    NewRepositoryNameValue("owner/repo"),
+	})
+
+	require.False(t, resp.Diagnostics.HasError())
+
+	state := &RepositoryState{}
+	require.False(t, resp.State.Get(t.Context(), state).HasError())
+	require.Equal(t, stored.Token, state.Token.ValueString())
+	require.Equal(t, 1, api.Calls(fake.OperationList))
+	require.Equal(t, 0, api.Calls(fake.OperationGet))
+}
+
+func TestRepositoryDataSourceReadMissingService(t *testing.T) {
+	d := &RepositoryDataSource{
+		coveralls: testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+			t.Fatal("unexpected request")
+		}),
+	}
+
+	resp := testRepositoryDataSourceRead(t, d, &RepositoryState{
+		Name: NewRepositoryNameValue("owner/repo"),
+	})
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.Equal(t, "Missing service", resp.Diagnostics.Errors()[0].Summary())
+}
+
+func testRepositoryDataSourceRead(t *testing.T, d *RepositoryDataSource, config *RepositoryState) *datasource.ReadResponse {
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(t.Context(), datasource.SchemaRequest{}, schemaResp)
+
+	state := tfsdk.State{Schema: schemaResp.Schema}
+	require.False(t, state.Set(t.Context(), config).HasError())
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(t.Context(), datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: state.Raw}}, resp)
+
+	return resp
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"terraform-provider-coveralls/internal/provider/client"
+)
+
+const (
+	// privateValidatorsKey holds the validators of the last read of a repository in resource private state.
+	privateValidatorsKey = "validators"
+)
+
+// privateStateReader and privateStateWriter are implemented by the private state of resource requests and responses.
+type privateStateReader interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+type privateStateWriter interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// restoreValidators seeds the client with the validators held in private state and the repository in state that they
+// apply to, so that reading an unchanged repository doesn't download it again. It returns true if validators were found.
+func restoreValidators(ctx context.Context, api client.API, private privateStateReader, state *RepositoryState) (bool, diag.Diagnostics) {
+	cache, ok := api.(client.ValidatorCache)
+	if !ok || private == nil {
+		return false, nil
+	}
+
+	value, diags := private.GetKey(ctx, privateValidatorsKey)
+	if diags.HasError() || len(value) == 0 {
+		return false, diags
+	}
+
+	validators := client.Validators{}
+	if err := json.Unmarshal(value, &validators); err != nil {
+		// not worth failing the read for, the repository is just downloaded again
+		tflog.Debug(ctx, "Ignoring invalid validators in private state", map[string]any{"error": err.Error()})
+		return true, diags
+	}
+
+	// the repository is read using its id, which has the case of the name returned by the api, see resourceState
+	service, name, ok := strings.Cut(state.Id.ValueString(), ":")
+
+	// without an id or token the state can't be used in place of the api response
+	if !ok || state.Token.ValueString() == "" {
+		return true, diags
+	}
+
+	repository := setRepositoryConfig(state)
+	repository.Service = service
+	repository.Name = name
+	repository.Token = state.Token.ValueString()
+	repository.CreatedAt = state.CreatedAt.ValueString()
+	repository.UpdatedAt = state.UpdatedAt.ValueString()
+
+	cache.SeedCache(repository.Service, repository.Name, validators, repository)
+
+	return true, diags
+}
+
+// persistValidators stores the validators of the last read of the repository in private state. If there are none and
+// validators were previously stored, they are removed.
+func persistValidators(ctx context.Context, api client.API, private privateStateWriter, service, name string, stored bool) diag.Diagnostics {
+	cache, ok := api.(client.ValidatorCache)
+	if !ok || private == nil {
+		return nil
+	}
+
+	validators, ok := cache.CachedValidators(service, name)
+	if !ok || validators.IsEmpty() {
+		if stored {
+			return private.SetKey(ctx, privateValidatorsKey, nil)
+		}
+		return nil
+	}
+
+	value, err := json.Marshal(validators)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Unable to store repository validators", err.Error())
+		return diags
+	}
+
+	return private.SetKey(ctx, privateValidatorsKey, value)
+}
@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+	"terraform-provider-coveralls/internal/provider/client/coverallstest"
+	"terraform-provider-coveralls/internal/provider/client/fake"
+)
+
+// testPrivateState stores private state in memory, like the framework an empty value removes the key.
+type testPrivateState map[string][]byte
+
+func (p testPrivateState) GetKey(_ context.Context, key string) ([]byte, diag.Diagnostics) {
+	return p[key], nil
+}
+
+func (p testPrivateState) SetKey(_ context.Context, key string, value []byte) diag.Diagnostics {
+	if len(value) == 0 {
+		delete(p, key)
+		return nil
+	}
+	p[key] = value
+	return nil
+}
+
+func TestValidatorsPrivateState(t *testing.T) {
+	ctx := t.Context()
+	coveralls := testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"service": "github", "name": "owner/repo", "token": "repo-token"}`))
+	})
+
+	private := testPrivateState{}
+
+	restored, diags := restoreValidators(ctx, coveralls.client, private, &RepositoryState{})
+	require.False(t, diags.HasError())
+	require.False(t, restored)
+
+	_, err := coveralls.client.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+
+	require.False(t, persistValidators(ctx, coveralls.client, private, "github", "owner/repo", false).HasError())
+	require.JSONEq(t, `{"etag": "\"v1\""}`, string(private[privateValidatorsKey]))
+
+	// a new provider process is seeded from state, so the repository isn't downloaded again
+	coveralls = testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, `"v1"`, req.Header.Get("If-None-Match"))
+		w.WriteHeader(http.StatusNotModified)
+	})
+
+	// the configured name may differ in case from the name returned by the api, which the id has
+	restored, diags = restoreValidators(ctx, coveralls.client, private, &RepositoryState{
+		Id:                    types.StringValue("github:owner/repo"),
+		Service:               types.StringValue("github"),
+		Name:                  NewRepositoryNameValue("Owner/Repo"),
+		Token:                 types.StringValue("repo-token"),
+		CommentOnPullRequests: types.BoolValue(true),
+	})
+	require.False(t, diags.HasError())
+	require.True(t, restored)
+
+	repository, err := coveralls.client.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, "repo-token", repository.Token)
+	require.True(t, repository.CommentOnPullRequests)
+}
+
+func TestValidatorsPrivateStatePrefetch(t *testing.T) {
+	ctx := t.Context()
+	server := coverallstest.NewServer(t)
+
+	names := []string{"owner/a", "owner/b", "owner/c"}
+	states := map[string]*RepositoryState{}
+	privates := map[string]testPrivateState{}
+
+	// the repositories were read by a previous run, which stored their validators in private state
+	previous, err := client.NewCoveralls(server.URL, coverallstest.Token)
+	require.NoError(t, err)
+
+	for _, name := range names {
+		server.Fake().AddRepository(&client.Repository{Service: "github", Name: name})
+
+		repository, err := previous.Get(ctx, "github", name)
+		require.NoError(t, err)
+
+		states[name] = repositoryConverter()(repository)
+		privates[name] = testPrivateState{}
+		require.False(t, persistValidators(ctx, previous, privates[name], "github", name, false).HasError())
+		require.NotEmpty(t, privates[name])
+	}
+
+	// one repository is missing from the listing, eg: as it was created after the listing was fetched
+	missing, _ := server.Fake().Repository("github", "owner/c")
+	server.Fake().DeleteRepository("github", "owner/c")
+
+	// an unchanged repository is returned from state, rather than downloaded again
+	states["owner/c"].Token = types.StringValue("token-in-state")
+
+	c, err := client.NewCoveralls(server.URL, coverallstest.Token)
+	require.NoError(t, err)
+
+	coveralls := &Coveralls{client: c, converter: repositoryConverter(), repositories: newRepositoryCache(c, time.Minute)}
+	before := len(server.Requests())
+
+	for _, name := range names {
+		if name == "owner/c" {
+			server.Fake().AddRepository(missing)
+		}
+
+		restored, diags := restoreValidators(ctx, c, privates[name], states[name])
+		require.False(t, diags.HasError())
+		require.True(t, restored)
+
+		repository, err := coveralls.getRepository(ctx, "github", name)
+		require.NoError(t, err)
+		require.Equal(t, states[name].Token.ValueString(), repository.Token)
+	}
+
+	// the listing serves the repositories in it, only the missing one is read, and conditionally
+	require.Equal(t, []string{"GET /api/repos", "GET /api/repos/github/owner/c"}, server.Requests()[before:])
+}
+
+func TestValidatorsPrivateStateRemoved(t *testing.T) {
+	ctx := t.Context()
+	coveralls := testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"service": "github", "name": "owner/repo", "token": "repo-token"}`))
+	})
+
+	private := testPrivateState{privateValidatorsKey: []byte(`{"etag": "\"v1\""}`)}
+
+	restored, diags := restoreValidators(ctx, coveralls.client, private, &RepositoryState{
+		Id:      types.StringValue("github:owner/repo"),
+		Service: types.StringValue("github"),
+		Name:    NewRepositoryNameValue("owner/repo"),
+		Token:   types.StringValue("repo-token"),
+	})
+	require.False(t, diags.HasError())
+	require.True(t, restored)
+
+	// the api no longer returns validators
+	_, err := coveralls.client.Get(ctx, "github", "owner/repo")
+	require.NoError(t, err)
+
+	require.False(t, persistValidators(ctx, coveralls.client, private, "github", "owner/repo", restored).HasError())
+	require.Empty(t, private)
+}
+
+func TestValidatorsPrivateStateUnsupported(t *testing.T) {
+	private := testPrivateState{privateValidatorsKey: []byte(`{"etag": "\"v1\""}`)}
+
+	restored, diags := restoreValidators(t.Context(), fake.New(), private, &RepositoryState{})
+	require.False(t, diags.HasError())
+	require.False(t, restored)
+
+	require.False(t, persistValidators(t.Context(), fake.New(), private, "github", "owner/repo", true).HasError())
+	require.Len(t, private, 1)
+}
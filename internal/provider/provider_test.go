@@ -1,21 +1,28 @@
 package provider
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+	"terraform-provider-coveralls/internal/provider/client/cassette"
+	"terraform-provider-coveralls/internal/provider/client/coverallstest"
+	"terraform-provider-coveralls/internal/provider/client/fake"
 )
 
 const (
 	service = "github"
 	name    = "jgangemi/terraform-coveralls-test"
-
-	providerConfig = `
-provider "coveralls" {
-}
-`
 )
 
 var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
@@ -23,7 +30,92 @@ var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServe
 }
 
 func testAccPreCheck(t *testing.T) {
-	if os.Getenv("COVERALLS_API_TOKEN") == "" {
-		t.Fatal("COVERALLS_API_TOKEN must be set for acceptance tests")
+	// the provider is configured explicitly, make sure nothing in the environment overrides it
+	for _, env := range []string{"COVERALLS_PROXY_URL", "COVERALLS_DEFAULT_SERVICE", "COVERALLS_SKIP_CREDENTIALS_VALIDATION"} {
+		if os.Getenv(env) != "" {
+			t.Fatalf("%s must not be set for acceptance tests", env)
+		}
+	}
+}
+
+// testAccProviderConfig configures the provider to use the local stand-in server.
+func testAccProviderConfig(server *coverallstest.Server) string {
+	return fmt.Sprintf(`
+provider "coveralls" {
+  endpoint = %q
+  token    = %q
+}
+`, server.URL, coverallstest.Token)
+}
+
+// testAccCassette returns provider factories and configuration for acceptance tests run against the real Coveralls
+// api. Exchanges are recorded to, or replayed from, 'testdata/cassettes/<test name>.json' depending on
+// COVERALLS_CASSETTE_MODE, recording or passing requests through requires COVERALLS_API_TOKEN.
+func testAccCassette(t *testing.T) (map[string]func() (tfprotov6.ProviderServer, error), string) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("Acceptance tests skipped unless env 'TF_ACC' set")
+	}
+
+	mode, err := cassette.ModeFromEnv()
+	require.NoError(t, err)
+
+	// the token isn't sent when replaying, any value will do
+	token := cassette.Redacted
+	if mode != cassette.ModeReplay {
+		token = os.Getenv("COVERALLS_API_TOKEN")
+		if token == "" {
+			t.Fatalf("COVERALLS_API_TOKEN must be set when %s is %q", cassette.ModeEnv, mode)
+		}
+	}
+
+	c, err := cassette.Load(filepath.Join("testdata", "cassettes", t.Name()+".json"), mode, token)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("No cassette recorded, run with %s=record to record one", cassette.ModeEnv)
 	}
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, c.Save()) })
+
+	factories := map[string]func() (tfprotov6.ProviderServer, error){
+		"coveralls": providerserver.NewProtocol6WithError(&CoverallsProvider{
+			version: "test",
+			options: []client.Option{client.WithTransport(c.Transport)},
+		}),
+	}
+
+	return factories, fmt.Sprintf(`
+provider "coveralls" {
+  token = %q
+}
+`, token)
+}
+
+// testCoveralls returns provider data whose client talks to a local server using the given handler.
+func testCoveralls(t *testing.T, handler http.HandlerFunc) *Coveralls {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := client.NewCoveralls(server.URL, "fake-token", client.WithRetries(0, time.Millisecond))
+	require.NoError(t, err)
+
+	return &Coveralls{
+		client:    c,
+		converter: repositoryConverter(),
+	}
+}
+
+// testFakeCoveralls returns provider data backed by an in-memory fake of the api.
+func testFakeCoveralls() (*Coveralls, *fake.Coveralls) {
+	api := fake.New()
+
+	return &Coveralls{
+		client:    api,
+		converter: repositoryConverter(),
+	}, api
+}
+
+func TestUserAgent(t *testing.T) {
+	require.Equal(t, "terraform-provider-coveralls/dev", userAgent("", "", ""))
+	require.Equal(t, "terraform-provider-coveralls/1.0.0 (+terraform 1.9.0)", userAgent("1.0.0", "1.9.0", ""))
+	require.Equal(t, "terraform-provider-coveralls/1.0.0 (+terraform 1.9.0) team-a/pipeline",
+		userAgent("1.0.0", "1.9.0", " team-a/pipeline "))
 }
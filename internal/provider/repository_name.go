@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var (
+	_ basetypes.StringTypable                    = RepositoryNameType{}
+	_ basetypes.StringValuableWithSemanticEquals = RepositoryNameValue{}
+)
+
+// RepositoryNameType is the type of repository names. Git providers treat names case-insensitively, so values that
+// only differ by case are semantically equal, eg: 'Owner/Repo' and 'owner/repo'.
+type RepositoryNameType struct {
+	basetypes.StringType
+}
+
+func (t RepositoryNameType) Equal(o attr.Type) bool {
+	other, ok := o.(RepositoryNameType)
+	if !ok {
+		return false
+	}
+
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t RepositoryNameType) String() string {
+	return "RepositoryNameType"
+}
+
+func (t RepositoryNameType) ValueFromString(_ context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return RepositoryNameValue{StringValue: in}, nil
+}
+
+func (t RepositoryNameType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	stringValuable, diags := t.ValueFromString(ctx, stringValue)
+	if diags.HasError() {
+		return nil, fmt.Errorf("unexpected error converting StringValue to StringValuable: %v", diags)
+	}
+
+	return stringValuable, nil
+}
+
+func (t RepositoryNameType) ValueType(_ context.Context) attr.Value {
+	return RepositoryNameValue{}
+}
+
+// RepositoryNameValue is a repository name, see RepositoryNameType.
+type RepositoryNameValue struct {
+	basetypes.StringValue
+}
+
+func NewRepositoryNameValue(value string) RepositoryNameValue {
+	return RepositoryNameValue{StringValue: basetypes.NewStringValue(value)}
+}
+
+func NewRepositoryNameNull() RepositoryNameValue {
+	return RepositoryNameValue{StringValue: basetypes.NewStringNull()}
+}
+
+func (v RepositoryNameValue) Equal(o attr.Value) bool {
+	other, ok := o.(RepositoryNameValue)
+	if !ok {
+		return false
+	}
+
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v RepositoryNameValue) Type(_ context.Context) attr.Type {
+	return RepositoryNameType{}
+}
+
+// StringSemanticEquals returns true if the names only differ by case, keeping the value already in state when the
+// api returns a name with different case to the one configured.
+func (v RepositoryNameValue) StringSemanticEquals(_ context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(RepositoryNameValue)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			"An unexpected value type was received while performing semantic equality checks. "+
+				"Please report this to the provider developers.\n\n"+
+				"Expected Value Type: "+fmt.Sprintf("%T", v)+"\n"+
+				"Got Value Type: "+fmt.Sprintf("%T", newValuable),
+		)
+		return false, diags
+	}
+
+	return strings.EqualFold(v.ValueString(), newValue.ValueString()), diags
+}
@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryNameSemanticEquals(t *testing.T) {
+	ctx := t.Context()
+	name := NewRepositoryNameValue("Owner/Repo")
+
+	equal, diags := name.StringSemanticEquals(ctx, NewRepositoryNameValue("owner/repo"))
+	require.False(t, diags.HasError())
+	require.True(t, equal)
+
+	equal, diags = name.StringSemanticEquals(ctx, NewRepositoryNameValue("owner/other"))
+	require.False(t, diags.HasError())
+	require.False(t, equal)
+
+	_, diags = name.StringSemanticEquals(ctx, types.StringValue("owner/repo"))
+	require.True(t, diags.HasError())
+
+	// case matters for value equality, so changes are still shown in plans
+	require.False(t, name.Equal(NewRepositoryNameValue("owner/repo")))
+	require.True(t, name.Equal(NewRepositoryNameValue("Owner/Repo")))
+	require.False(t, name.Equal(types.StringValue("Owner/Repo")))
+}
+
+func TestRepositoryNameType(t *testing.T) {
+	ctx := t.Context()
+
+	value, err := RepositoryNameType{}.ValueFromTerraform(ctx, tftypes.NewValue(tftypes.String, "owner/repo"))
+	require.NoError(t, err)
+	require.Equal(t, NewRepositoryNameValue("owner/repo"), value)
+
+	value, err = RepositoryNameType{}.ValueFromTerraform(ctx, tftypes.NewValue(tftypes.String, nil))
+	require.NoError(t, err)
+	require.Equal(t, NewRepositoryNameNull(), value)
+
+	require.True(t, RepositoryNameType{}.Equal(value.Type(ctx)))
+	require.False(t, RepositoryNameType{}.Equal(types.StringType))
+}
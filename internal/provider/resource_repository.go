@@ -2,32 +2,63 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/float64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
 	"terraform-provider-coveralls/internal/provider/client"
 )
 
 var (
-	_ resource.Resource              = &RepositoryResource{}
-	_ resource.ResourceWithConfigure = &RepositoryResource{}
+	_ resource.Resource                     = &RepositoryResource{}
+	_ resource.ResourceWithConfigure        = &RepositoryResource{}
+	_ resource.ResourceWithConfigValidators = &RepositoryResource{}
+	_ resource.ResourceWithModifyPlan       = &RepositoryResource{}
+	_ resource.ResourceWithImportState      = &RepositoryResource{}
 )
 
 func NewRepositoryResource() resource.Resource {
 	return &RepositoryResource{}
 }
 
+const (
+	defaultCreateTimeout = 5 * time.Minute
+	defaultDeleteTimeout = 5 * time.Minute
+	defaultReadTimeout   = 5 * time.Minute
+	defaultUpdateTimeout = 5 * time.Minute
+
+	// on_destroy modes
+	onDestroyAbandon = "abandon"
+	onDestroyReset   = "reset"
+	onDestroyDelete  = "delete"
+)
+
 type RepositoryResource struct {
 	coveralls *Coveralls
 }
 
+type RepositoryResourceState struct {
+	RepositoryState
+	OnDestroy types.String   `tfsdk:"on_destroy"`
+	Timeouts  timeouts.Value `tfsdk:"timeouts"`
+}
+
 func (r *RepositoryResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_repository"
 }
@@ -35,18 +66,48 @@ func (r *RepositoryResource) Metadata(ctx context.Context, req resource.Metadata
 func (r *RepositoryResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Use this resource to manage a Coveralls repository.",
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
 		Attributes: map[string]schema.Attribute{
 			"comment_on_pull_requests": schema.BoolAttribute{
-				Description: "Whether comments should be posted on pull requests.",
-				Required:    true,
+				Description: "Whether comments should be posted on pull requests. If not set, new repositories " +
+					"get the Coveralls default and existing repositories keep their current setting.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"commit_status_fail_threshold": schema.Float64Attribute{
-				Description: "Minimum coverage that must be present on a build for the build to pass.",
-				Optional:    true,
+				Description: "Minimum coverage that must be present on a build for the build to pass, between 0 and " +
+					"100. Has no effect unless send_build_status is true. If not set, the current threshold is kept.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Float64{
+					float64planmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.Float64{
+					thresholdValidator(),
+				},
 			},
 			"commit_status_fail_change_threshold": schema.Float64Attribute{
-				Description: "Maximum allowed amount of decrease that will be allowed for the build to pass.",
-				Optional:    true,
+				Description: "Maximum allowed amount of decrease that will be allowed for the build to pass, between " +
+					"0 and 100. Has no effect unless send_build_status is true. If not set, the current threshold is " +
+					"kept.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Float64{
+					float64planmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.Float64{
+					thresholdValidator(),
+				},
 			},
 			"created_at": schema.StringAttribute{
 				Description: "Date and time when the Coveralls repository was created.",
@@ -63,16 +124,57 @@ func (r *RepositoryResource) Schema(ctx context.Context, req resource.SchemaRequ
 				},
 			},
 			"name": schema.StringAttribute{
-				MarkdownDescription: "Name of the repository in the form `<owner>/<name>`.",
-				Required:            true,
+				MarkdownDescription: "Name of the repository in the form `<owner>/<name>`, compared case-insensitively. " +
+					"Changing the name forces a new resource to be created.",
+				CustomType: RepositoryNameType{},
+				Required:   true,
+				Validators: []validator.String{
+					repositoryNameValidator(),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIf(
+						requiresReplaceIfNameChanged,
+						"Changing the name, other than its case, forces a new resource to be created.",
+						"Changing the name, other than its case, forces a new resource to be created.",
+					),
+				},
+			},
+			"on_destroy": schema.StringAttribute{
+				MarkdownDescription: "What happens to the repository when the resource is destroyed, one of `abandon`, " +
+					"`reset` or `delete`. `abandon` leaves the repository unchanged in Coveralls, `reset` restores the " +
+					"settings of a newly added repository (pull request comments and build status enabled, no " +
+					"thresholds) and `delete` deletes it, where supported by the Coveralls installation. Defaults to " +
+					"`abandon`.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(onDestroyAbandon),
+				Validators: []validator.String{
+					stringvalidator.OneOf(onDestroyAbandon, onDestroyReset, onDestroyDelete),
+				},
 			},
 			"send_build_status": schema.BoolAttribute{
-				Description: "Whether build status should be sent to the git provider.",
-				Required:    true,
+				Description: "Whether build status should be sent to the git provider. If not set, new repositories " +
+					"get the Coveralls default and existing repositories keep their current setting.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"service": schema.StringAttribute{
-				MarkdownDescription: "Git provider, eg: `github`",
-				Required:            true,
+				MarkdownDescription: "Git provider, one of `github`, `gitlab`, `bitbucket`, `stash`, " +
+					"`github_enterprise` or `gitlab_enterprise`. Defaults to the provider `default_service`. Changing " +
+					"the service forces a new resource to be created.",
+				Optional: true,
+				Computed: true,
+				Validators: []validator.String{
+					serviceValidator(),
+				},
+				PlanModifiers: []planmodifier.String{
+					// keeps the service in state if it isn't configured, the provider default is applied by ModifyPlan
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"token": schema.StringAttribute{
 				Description: "Repository Token.",
@@ -90,6 +192,12 @@ func (r *RepositoryResource) Schema(ctx context.Context, req resource.SchemaRequ
 	}
 }
 
+func (r *RepositoryResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		repositorySettingsValidator{},
+	}
+}
+
 func (r *RepositoryResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
@@ -108,8 +216,50 @@ func (r *RepositoryResource) Configure(ctx context.Context, req resource.Configu
 	r.coveralls = coveralls
 }
 
+func (r *RepositoryResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// nothing to do when destroying, or before the provider has been configured
+	if req.Plan.Raw.IsNull() || r.coveralls == nil {
+		return
+	}
+
+	var service types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("service"), &service)...)
+
+	if resp.Diagnostics.HasError() || !service.IsNull() {
+		return
+	}
+
+	if r.coveralls.defaultService == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("service"),
+			"Missing service",
+			"The service must be set on the resource or using the provider default_service attribute.",
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("service"), r.coveralls.defaultService)...)
+
+	// the attribute plan modifiers only see the configured service, so a change of default is handled here
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var prior types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("service"), &prior)...)
+
+	if !prior.IsNull() && prior.ValueString() != r.coveralls.defaultService {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root("service"))
+	}
+}
+
+// requiresReplaceIfNameChanged forces replacement if the name has changed, other than by case.
+func requiresReplaceIfNameChanged(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+	resp.RequiresReplace = !strings.EqualFold(req.PlanValue.ValueString(), req.StateValue.ValueString())
+}
+
 func (r *RepositoryResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	plan := &RepositoryState{}
+	plan := &RepositoryResourceState{}
 	diags := req.Plan.Get(ctx, plan)
 
 	resp.Diagnostics.Append(diags...)
@@ -117,12 +267,25 @@ func (r *RepositoryResource) Create(ctx context.Context, req resource.CreateRequ
 		return
 	}
 
-	repository := setRepositoryConfig(plan)
-	// these are required on the struct during creation
-	repository.Name = plan.Name.ValueString()
-	repository.Service = plan.Service.ValueString()
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCreateTimeout)
 
-	_, err := r.coveralls.client.Create(ctx, repository)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	// settings that weren't configured are left out, so Coveralls uses its defaults for them
+	create := &client.RepositoryCreate{
+		Service:          r.coveralls.service(plan.Service),
+		Name:             plan.Name.ValueString(),
+		RepositoryUpdate: *repositoryUpdate(&RepositoryState{}, &plan.RepositoryState),
+	}
+
+	_, err := r.coveralls.client.Create(ctx, create)
+	r.coveralls.repositories.Invalidate(create.Service, create.Name)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -132,8 +295,18 @@ func (r *RepositoryResource) Create(ctx context.Context, req resource.CreateRequ
 		return
 	}
 
-	// the 'token' isn't available on initial creation, so an additional read call is necessary
-	repository, err = r.coveralls.client.Get(ctx, repository.Service, repository.Name)
+	// the 'token' isn't available on initial creation, so it is read back once the api reflects the write
+	created, err := waitForConsistency(ctx, r.coveralls.client, create.Service, create.Name, &create.RepositoryUpdate)
+
+	if errors.Is(err, errInconsistent) {
+		resp.Diagnostics.AddError(
+			"Inconsistent repository after create",
+			fmt.Sprintf("Repository %q was created but Coveralls did not return its token and settings before the "+
+				"create timeout expired, so it has not been saved to state: %s. Import the repository once it is "+
+				"consistent, or increase the create timeout.", create.Service+":"+create.Name, err.Error()),
+		)
+		return
+	}
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -143,12 +316,14 @@ func (r *RepositoryResource) Create(ctx context.Context, req resource.CreateRequ
 		return
 	}
 
-	diags = resp.State.Set(ctx, r.coveralls.converter(repository))
+	diags = resp.State.Set(ctx, r.resourceState(created, plan))
 	resp.Diagnostics.Append(diags...)
+
+	resp.Diagnostics.Append(persistValidators(ctx, r.coveralls.client, resp.Private, created.Service, created.Name, false)...)
 }
 
 func (r *RepositoryResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	state := &RepositoryState{}
+	state := &RepositoryResourceState{}
 	diags := req.State.Get(ctx, state)
 
 	resp.Diagnostics.Append(diags...)
@@ -156,8 +331,40 @@ func (r *RepositoryResource) Read(ctx context.Context, req resource.ReadRequest,
 		return
 	}
 
-	id := strings.Split(state.Id.ValueString(), ":")
-	repository, err := r.coveralls.client.Get(ctx, id[0], id[1])
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultReadTimeout)
+
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	restored, diags := restoreValidators(ctx, r.coveralls.client, req.Private, &state.RepositoryState)
+
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	service, name, err := parseRepositoryId(state.Id.ValueString(), "")
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid repository id", err.Error())
+		return
+	}
+
+	repository, err := r.coveralls.getRepository(ctx, service, name)
+
+	if errors.Is(err, client.ErrNotFound) {
+		resp.Diagnostics.AddWarning(
+			"Repository not found",
+			fmt.Sprintf("Repository %q was not found in Coveralls and has been removed from state, "+
+				"it will be re-created on the next apply.", state.Id.ValueString()),
+		)
+		resp.State.RemoveResource(ctx)
+		return
+	}
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -167,12 +374,14 @@ func (r *RepositoryResource) Read(ctx context.Context, req resource.ReadRequest,
 		return
 	}
 
-	diags = resp.State.Set(ctx, r.coveralls.converter(repository))
+	diags = resp.State.Set(ctx, r.resourceState(repository, state))
 	resp.Diagnostics.Append(diags...)
+
+	resp.Diagnostics.Append(persistValidators(ctx, r.coveralls.client, resp.Private, service, name, restored)...)
 }
 
 func (r *RepositoryResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	plan := &RepositoryState{}
+	plan := &RepositoryResourceState{}
 	diags := req.Plan.Get(ctx, plan)
 
 	resp.Diagnostics.Append(diags...)
@@ -180,22 +389,59 @@ func (r *RepositoryResource) Update(ctx context.Context, req resource.UpdateRequ
 		return
 	}
 
-	id := strings.Split(plan.Id.ValueString(), ":")
-	repository := setRepositoryConfig(plan)
+	prior := &RepositoryResourceState{}
+	diags = req.State.Get(ctx, prior)
+
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, defaultUpdateTimeout)
 
-	_, err := r.coveralls.client.Update(ctx, id[0], id[1], repository)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	service, name, err := parseRepositoryId(plan.Id.ValueString(), "")
 	if err != nil {
+		resp.Diagnostics.AddError("Invalid repository id", err.Error())
+		return
+	}
+
+	update := repositoryUpdate(&prior.RepositoryState, &plan.RepositoryState)
+
+	// there is nothing to send if only the attributes that aren't known to the api have changed, eg: on_destroy
+	if !update.IsEmpty() {
+		_, err := r.coveralls.client.Update(ctx, service, name, update)
+		r.coveralls.repositories.Invalidate(service, name)
+
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error updating repository",
+				"Could not update repository, unexpected error: "+err.Error(),
+			)
+			return
+		}
+	}
+
+	// the 'token' isn't returned by the update, so it is read back once the api reflects the settings that were sent
+	repository, err := waitForConsistency(ctx, r.coveralls.client, service, name, update)
+
+	if errors.Is(err, errInconsistent) {
 		resp.Diagnostics.AddError(
-			"Error updating repository",
-			"Could not update repository, unexpected error: "+err.Error(),
+			"Inconsistent repository after update",
+			fmt.Sprintf("Repository %q was updated but Coveralls did not return the new settings before the update "+
+				"timeout expired, so the previous state has been kept: %s. Re-run apply once the repository is "+
+				"consistent, or increase the update timeout.", plan.Id.ValueString(), err.Error()),
 		)
 		return
 	}
 
-	// the 'token' isn't available on initial creation, so an additional read call is necessary
-	repository, err = r.coveralls.client.Get(ctx, id[0], id[1])
-
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error updating repository",
@@ -204,18 +450,205 @@ func (r *RepositoryResource) Update(ctx context.Context, req resource.UpdateRequ
 		return
 	}
 
-	diags = resp.State.Set(ctx, r.coveralls.converter(repository))
+	diags = resp.State.Set(ctx, r.resourceState(repository, plan))
 	resp.Diagnostics.Append(diags...)
+
+	resp.Diagnostics.Append(persistValidators(ctx, r.coveralls.client, resp.Private, service, name, true)...)
 }
 
 func (r *RepositoryResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	tflog.Warn(ctx, "Delete not supported by Coveralls API")
+	state := &RepositoryResourceState{}
+	diags := req.State.Get(ctx, state)
+
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultDeleteTimeout)
+
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	service, name, err := parseRepositoryId(state.Id.ValueString(), "")
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid repository id", err.Error())
+		return
+	}
+
+	ctx = tflog.SetField(ctx, "on_destroy", state.OnDestroy.ValueString())
+
+	switch state.OnDestroy.ValueString() {
+	case onDestroyReset:
+		_, err := r.coveralls.client.Update(ctx, service, name, client.NewRepositoryUpdate(defaultRepositorySettings()))
+		r.coveralls.repositories.Invalidate(service, name)
+
+		// there is nothing to reset if it has already been deleted
+		if err != nil && !errors.Is(err, client.ErrNotFound) {
+			resp.Diagnostics.AddError(
+				"Error resetting repository",
+				"Could not reset repository settings, unexpected error: "+err.Error(),
+			)
+		}
+	case onDestroyDelete:
+		err := r.coveralls.client.Delete(ctx, service, name)
+		r.coveralls.repositories.Invalidate(service, name)
+
+		// an api without a delete endpoint answers not found too, so the repository is only taken to have already
+		// been deleted if reading it agrees
+		if errors.Is(err, client.ErrNotFound) {
+			err = r.confirmDeleted(ctx, service, name)
+		}
+
+		if errors.Is(err, client.ErrNotSupported) {
+			resp.Diagnostics.AddError(
+				"Repository deletion not supported",
+				fmt.Sprintf("The Coveralls api does not support deleting repository %q. Set on_destroy to %q or %q "+
+					"to remove it from state.", state.Id.ValueString(), onDestroyAbandon, onDestroyReset),
+			)
+			return
+		}
+
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error deleting repository",
+				"Could not delete repository, unexpected error: "+err.Error(),
+			)
+		}
+	default:
+		resp.Diagnostics.AddWarning(
+			"Repository abandoned",
+			fmt.Sprintf("Repository %q has been removed from state but still exists in Coveralls with its current "+
+				"settings. Set on_destroy to %q or %q to change this.", state.Id.ValueString(), onDestroyReset,
+				onDestroyDelete),
+		)
+	}
 }
 
+// confirmDeleted reads a repository that the api reported as not found when deleting it, returning nil if it has been
+// deleted or an error matching client.ErrNotSupported if it still exists.
+func (r *RepositoryResource) confirmDeleted(ctx context.Context, service, name string) error {
+	_, err := r.coveralls.client.Get(ctx, service, name)
+
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	default:
+		return fmt.Errorf("%w: the repository still exists after deleting it", client.ErrNotSupported)
+	}
+}
+
+// ImportState imports a repository by its id, '<service>:<owner>/<name>'. The service may be omitted if the provider
+// has a default_service.
 func (r *RepositoryResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	service, name, err := parseRepositoryId(req.ID, r.coveralls.defaultService)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid import id",
+			fmt.Sprintf("Expected an id of the form '<service>:<owner>/<name>', or '<owner>/<name>' if the provider "+
+				"has a default_service: %s", err.Error()),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), service+":"+name)...)
+}
+
+// parseRepositoryId splits an id into the service and name of the repository, using defaultService if the id doesn't
+// have one.
+func parseRepositoryId(id, defaultService string) (string, string, error) {
+	service, name, ok := strings.Cut(id, ":")
+	if !ok {
+		service, name = defaultService, id
+	}
+
+	if service == "" {
+		return "", "", fmt.Errorf("id %q doesn't include a service", id)
+	}
+
+	if !isKnownService(service) {
+		return "", "", fmt.Errorf("id %q has an unknown service, expected one of %s", id, strings.Join(knownServices, ", "))
+	}
+
+	if !repositoryNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("id %q doesn't include a name of the form <owner>/<name>", id)
+	}
+
+	return service, name, nil
+}
+
+// resourceState converts the repository, retaining the configured attributes which aren't known to the api.
+func (r *RepositoryResource) resourceState(repository *client.Repository, prior *RepositoryResourceState) *RepositoryResourceState {
+	// imported repositories don't have a prior value
+	onDestroy := prior.OnDestroy
+	if onDestroy.IsNull() || onDestroy.IsUnknown() {
+		onDestroy = types.StringValue(onDestroyAbandon)
+	}
+
+	state := r.coveralls.converter(repository)
+
+	// keep the case of the name that was configured or imported, see RepositoryNameType
+	if !prior.Name.IsNull() && !prior.Name.IsUnknown() && strings.EqualFold(prior.Name.ValueString(), repository.Name) {
+		state.Name = prior.Name
+	}
+
+	// the id is planned from state, so it mustn't change when only the case of the name does
+	if !prior.Id.IsNull() && !prior.Id.IsUnknown() && strings.EqualFold(prior.Id.ValueString(), state.Id.ValueString()) {
+		state.Id = prior.Id
+	}
+
+	return &RepositoryResourceState{
+		RepositoryState: *state,
+		OnDestroy:       onDestroy,
+		Timeouts:        prior.Timeouts,
+	}
+}
+
+// defaultRepositorySettings are those of a newly added Coveralls repository, restored when on_destroy is 'reset'.
+func defaultRepositorySettings() *client.Repository {
+	return &client.Repository{
+		CommentOnPullRequests: true,
+		SendBuildStatus:       true,
+	}
+}
+
+// repositoryUpdate returns the settings that differ between the prior state and the plan, so that only those that
+// changed are sent and settings changed by others since the last refresh aren't overwritten.
+func repositoryUpdate(prior, plan *RepositoryState) *client.RepositoryUpdate {
+	update := &client.RepositoryUpdate{}
+
+	if changed(prior.CommentOnPullRequests, plan.CommentOnPullRequests) {
+		update.CommentOnPullRequests = client.NewField(plan.CommentOnPullRequests.ValueBoolPointer())
+	}
+
+	if changed(prior.SendBuildStatus, plan.SendBuildStatus) {
+		update.SendBuildStatus = client.NewField(plan.SendBuildStatus.ValueBoolPointer())
+	}
+
+	if changed(prior.FailThreshold, plan.FailThreshold) {
+		update.FailThreshold = client.NewField(plan.FailThreshold.ValueFloat64Pointer())
+	}
+
+	if changed(prior.FailChangeThreshold, plan.FailChangeThreshold) {
+		update.FailChangeThreshold = client.NewField(plan.FailChangeThreshold.ValueFloat64Pointer())
+	}
+
+	return update
+}
+
+// changed returns true if the planned value is known and differs from the prior one.
+func changed(prior, plan attr.Value) bool {
+	return !plan.IsUnknown() && !plan.Equal(prior)
 }
 
+// setRepositoryConfig converts the settings to a repository, settings that are null or unknown are false.
 func setRepositoryConfig(state *RepositoryState) *client.Repository {
 	return &client.Repository{
 		CommentOnPullRequests: state.CommentOnPullRequests.ValueBool(),
@@ -1,53 +1,912 @@
 package provider
 
-//import (
-//	"fmt"
-//	"testing"
-//
-//	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
-//)
-//
-//func TestAccExampleResource(t *testing.T) {
-//	resource.Test(t, resource.TestCase{
-//		PreCheck:                 func() { testAccPreCheck(t) },
-//		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
-//		Steps: []resource.TestStep{
-//			// Create and Read testing
-//			{
-//				Config: testAccExampleResourceConfig("one"),
-//				Check: resource.ComposeAggregateTestCheckFunc(
-//					resource.TestCheckResourceAttr("scaffolding_example.test", "configurable_attribute", "one"),
-//					resource.TestCheckResourceAttr("scaffolding_example.test", "defaulted", "example value when not configured"),
-//					resource.TestCheckResourceAttr("scaffolding_example.test", "id", "example-id"),
-//				),
-//			},
-//			// ImportState testing
-//			{
-//				ResourceName:      "scaffolding_example.test",
-//				ImportState:       true,
-//				ImportStateVerify: true,
-//				// This is not normally necessary, but is here because this
-//				// example code does not have an actual upstream service.
-//				// Once the Read method is able to refresh information from
-//				// the upstream service, this can be removed.
-//				ImportStateVerifyIgnore: []string{"configurable_attribute", "defaulted"},
-//			},
-//			// Update and Read testing
-//			{
-//				Config: testAccExampleResourceConfig("two"),
-//				Check: resource.ComposeAggregateTestCheckFunc(
-//					resource.TestCheckResourceAttr("scaffolding_example.test", "configurable_attribute", "two"),
-//				),
-//			},
-//			// Delete testing automatically occurs in TestCase
-//		},
-//	})
-//}
-//
-//func testAccExampleResourceConfig(configurableAttribute string) string {
-//	return fmt.Sprintf(`
-//resource "scaffolding_example" "test" {
-//  configurable_attribute = %[1]q
-//}
-//`, configurableAttribute)
-//}
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/stretchr/testify/require"
+
+	"terraform-provider-coveralls/internal/provider/client"
+	"terraform-provider-coveralls/internal/provider/client/coverallstest"
+	"terraform-provider-coveralls/internal/provider/client/fake"
+)
+
+func TestAccRepositoryResource(t *testing.T) {
+	server := coverallstest.NewServer(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccProviderConfig(server) + testAccRepositoryResourceConfig(true, 80),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("coveralls_repository.test", "id", service+":"+name),
+					resource.TestCheckResourceAttr("coveralls_repository.test", "comment_on_pull_requests", "true"),
+					resource.TestCheckResourceAttr("coveralls_repository.test", "commit_status_fail_threshold", "80"),
+					resource.TestCheckResourceAttrSet("coveralls_repository.test", "token"),
+					resource.TestCheckResourceAttrSet("coveralls_repository.test", "created_at"),
+					testAccCheckRepository(server, func(repository *client.Repository) error {
+						if !repository.CommentOnPullRequests || *repository.FailThreshold != 80 {
+							return fmt.Errorf("unexpected repository settings: %+v", repository)
+						}
+						return nil
+					}),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:            "coveralls_repository.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"timeouts"},
+			},
+			// Update and Read testing
+			{
+				Config: testAccProviderConfig(server) + testAccRepositoryResourceConfig(false, 75.5),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("coveralls_repository.test", "comment_on_pull_requests", "false"),
+					resource.TestCheckResourceAttr("coveralls_repository.test", "commit_status_fail_threshold", "75.5"),
+					testAccCheckRepository(server, func(repository *client.Repository) error {
+						if repository.CommentOnPullRequests || *repository.FailThreshold != 75.5 {
+							return fmt.Errorf("unexpected repository settings: %+v", repository)
+						}
+						return nil
+					}),
+				),
+			},
+			// Removed outside of terraform testing
+			{
+				PreConfig: func() {
+					server.Fake().DeleteRepository(service, name)
+				},
+				Config:             testAccProviderConfig(server) + testAccRepositoryResourceConfig(false, 75.5),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+func TestAccRepositoryResourceOnDestroyDelete(t *testing.T) {
+	server := coverallstest.NewServer(t)
+	server.EnableDelete()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(_ *terraform.State) error {
+			if _, ok := server.Fake().Repository(service, name); ok {
+				return fmt.Errorf("repository %s:%s was not deleted", service, name)
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + fmt.Sprintf(`
+resource "coveralls_repository" "test" {
+  service                  = %q
+  name                     = %q
+  comment_on_pull_requests = true
+  send_build_status        = true
+  on_destroy               = "delete"
+}
+`, service, name),
+				Check: resource.TestCheckResourceAttr("coveralls_repository.test", "on_destroy", "delete"),
+			},
+		},
+	})
+}
+
+func TestAccRepositoryResourceOnDestroyDeleteUnsupported(t *testing.T) {
+	// like coveralls.io, the server doesn't support deleting repositories
+	server := coverallstest.NewServer(t)
+
+	config := func(onDestroy string) string {
+		return testAccProviderConfig(server) + fmt.Sprintf(`
+resource "coveralls_repository" "test" {
+  service    = %q
+  name       = %q
+  on_destroy = %q
+}
+`, service, name, onDestroy)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(_ *terraform.State) error {
+			if _, ok := server.Fake().Repository(service, name); !ok {
+				return fmt.Errorf("repository %s:%s was deleted", service, name)
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config(onDestroyDelete),
+			},
+			{
+				Config:      config(onDestroyDelete),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile("Repository deletion not supported"),
+			},
+			// the repository is kept in state, so it can be abandoned instead
+			{
+				Config: config(onDestroyAbandon),
+				Check:  resource.TestCheckResourceAttr("coveralls_repository.test", "on_destroy", onDestroyAbandon),
+			},
+		},
+	})
+}
+
+func TestAccRepositoryResourceReplace(t *testing.T) {
+	server := coverallstest.NewServer(t)
+
+	config := func(name string) string {
+		return testAccProviderConfig(server) + fmt.Sprintf(`
+resource "coveralls_repository" "test" {
+  service                  = %q
+  name                     = %q
+  comment_on_pull_requests = true
+  send_build_status        = true
+}
+`, service, name)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config("owner/repo"),
+			},
+			// a change of case isn't a new repository
+			{
+				Config: config("Owner/Repo"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("coveralls_repository.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("coveralls_repository.test", "name", "Owner/Repo"),
+					resource.TestCheckResourceAttr("coveralls_repository.test", "id", service+":owner/repo"),
+				),
+			},
+			{
+				Config: config("owner/other"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("coveralls_repository.test", plancheck.ResourceActionDestroyBeforeCreate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("coveralls_repository.test", "id", service+":owner/other"),
+					func(_ *terraform.State) error {
+						if _, ok := server.Fake().Repository(service, "owner/other"); !ok {
+							return fmt.Errorf("repository %s:owner/other was not created", service)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccRepositoryResourceUnmanagedSettings(t *testing.T) {
+	changeThreshold := 5.0
+	server := coverallstest.NewServer(t)
+	server.Fake().AddRepository(&client.Repository{
+		Service:               service,
+		Name:                  name,
+		CommentOnPullRequests: false,
+		SendBuildStatus:       true,
+		FailChangeThreshold:   &changeThreshold,
+	})
+
+	config := testAccProviderConfig(server) + fmt.Sprintf(`
+resource "coveralls_repository" "test" {
+  service                      = %q
+  name                         = %q
+  commit_status_fail_threshold = 80
+}
+`, service, name)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// adopt the existing repository without restating its settings
+			{
+				Config:             config,
+				ResourceName:       "coveralls_repository.test",
+				ImportState:        true,
+				ImportStateId:      service + ":" + name,
+				ImportStatePersist: true,
+			},
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("coveralls_repository.test", "comment_on_pull_requests", "false"),
+					resource.TestCheckResourceAttr("coveralls_repository.test", "send_build_status", "true"),
+					resource.TestCheckResourceAttr("coveralls_repository.test", "commit_status_fail_change_threshold", "5"),
+					testAccCheckRepository(server, func(repository *client.Repository) error {
+						if repository.CommentOnPullRequests || !repository.SendBuildStatus ||
+							repository.FailChangeThreshold == nil || *repository.FailChangeThreshold != 5 {
+							return fmt.Errorf("unmanaged settings were changed: %+v", repository)
+						}
+						if repository.FailThreshold == nil || *repository.FailThreshold != 80 {
+							return fmt.Errorf("commit_status_fail_threshold was not updated: %+v", repository)
+						}
+						return nil
+					}),
+				),
+			},
+		},
+	})
+}
+
+func testAccRepositoryResourceConfig(commentOnPullRequests bool, failThreshold float64) string {
+	return fmt.Sprintf(`
+resource "coveralls_repository" "test" {
+  service                      = %[1]q
+  name                         = %[2]q
+  comment_on_pull_requests     = %[3]t
+  send_build_status            = true
+  commit_status_fail_threshold = %[4]v
+}
+`, service, name, commentOnPullRequests, failThreshold)
+}
+
+func testAccCheckRepository(server *coverallstest.Server, check func(*client.Repository) error) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		repository, ok := server.Fake().Repository(service, name)
+		if !ok {
+			return fmt.Errorf("repository %s:%s not found", service, name)
+		}
+		return check(repository)
+	}
+}
+
+func TestRepositoryResourceReadNotFound(t *testing.T) {
+	ctx := t.Context()
+	r := &RepositoryResource{
+		coveralls: testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+			require.Equal(t, "/api/repos/github/owner/repo", req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}),
+	}
+
+	state := testRepositoryResourceState(t, r, &RepositoryState{
+		Id:      types.StringValue("github:owner/repo"),
+		Service: types.StringValue("github"),
+		Name:    NewRepositoryNameValue("owner/repo"),
+	})
+
+	resp := &fwresource.ReadResponse{State: state}
+	r.Read(ctx, fwresource.ReadRequest{State: state}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+	require.Len(t, resp.Diagnostics.Warnings(), 1)
+	require.True(t, resp.State.Raw.IsNull())
+}
+
+func TestRepositoryResourceReadError(t *testing.T) {
+	ctx := t.Context()
+	r := &RepositoryResource{
+		coveralls: testCoveralls(t, func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}),
+	}
+
+	state := testRepositoryResourceState(t, r, &RepositoryState{
+		Id: types.StringValue("github:owner/repo"),
+	})
+
+	resp := &fwresource.ReadResponse{State: state}
+	r.Read(ctx, fwresource.ReadRequest{State: state}, resp)
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.False(t, resp.State.Raw.IsNull())
+}
+
+func TestRepositoryResourceModifyPlanDefaultService(t *testing.T) {
+	ctx := t.Context()
+	r := &RepositoryResource{coveralls: &Coveralls{defaultService: "github"}}
+
+	config := testRepositoryResourceState(t, r, &RepositoryState{Name: NewRepositoryNameValue("owner/repo")})
+	plan := tfsdk.Plan{Schema: config.Schema, Raw: config.Raw}
+
+	resp := &fwresource.ModifyPlanResponse{Plan: plan}
+	r.ModifyPlan(ctx, fwresource.ModifyPlanRequest{
+		Config: tfsdk.Config{Schema: config.Schema, Raw: config.Raw},
+		Plan:   plan,
+	}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+
+	var service types.String
+	require.False(t, resp.Plan.GetAttribute(ctx, path.Root("service"), &service).HasError())
+	require.Equal(t, "github", service.ValueString())
+}
+
+func TestRepositoryResourceModifyPlanMissingService(t *testing.T) {
+	ctx := t.Context()
+	r := &RepositoryResource{coveralls: &Coveralls{}}
+
+	config := testRepositoryResourceState(t, r, &RepositoryState{Name: NewRepositoryNameValue("owner/repo")})
+	plan := tfsdk.Plan{Schema: config.Schema, Raw: config.Raw}
+
+	resp := &fwresource.ModifyPlanResponse{Plan: plan}
+	r.ModifyPlan(ctx, fwresource.ModifyPlanRequest{
+		Config: tfsdk.Config{Schema: config.Schema, Raw: config.Raw},
+		Plan:   plan,
+	}, resp)
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.Equal(t, "Missing service", resp.Diagnostics.Errors()[0].Summary())
+}
+
+func TestRepositoryResourceModifyPlanDefaultServiceChanged(t *testing.T) {
+	ctx := t.Context()
+	r := &RepositoryResource{coveralls: &Coveralls{defaultService: "gitlab"}}
+
+	config := testRepositoryResourceState(t, r, &RepositoryState{Name: NewRepositoryNameValue("owner/repo")})
+	state := testRepositoryResourceState(t, r, &RepositoryState{
+		Id:      types.StringValue("github:owner/repo"),
+		Service: types.StringValue("github"),
+		Name:    NewRepositoryNameValue("owner/repo"),
+	})
+	plan := tfsdk.Plan{Schema: state.Schema, Raw: state.Raw}
+
+	resp := &fwresource.ModifyPlanResponse{Plan: plan}
+	r.ModifyPlan(ctx, fwresource.ModifyPlanRequest{
+		Config: tfsdk.Config{Schema: config.Schema, Raw: config.Raw},
+		State:  state,
+		Plan:   plan,
+	}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+	require.Equal(t, path.Paths{path.Root("service")}, resp.RequiresReplace)
+
+	// an unchanged default doesn't
+	r.coveralls.defaultService = "github"
+
+	resp = &fwresource.ModifyPlanResponse{Plan: plan}
+	r.ModifyPlan(ctx, fwresource.ModifyPlanRequest{
+		Config: tfsdk.Config{Schema: config.Schema, Raw: config.Raw},
+		State:  state,
+		Plan:   plan,
+	}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+	require.Empty(t, resp.RequiresReplace)
+}
+
+func TestRequiresReplaceIfNameChanged(t *testing.T) {
+	tests := map[string]bool{
+		"owner/repo":  false,
+		"Owner/Repo":  false,
+		"owner/other": true,
+	}
+
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp := &stringplanmodifier.RequiresReplaceIfFuncResponse{}
+			requiresReplaceIfNameChanged(t.Context(), planmodifier.StringRequest{
+				StateValue: types.StringValue("owner/repo"),
+				PlanValue:  types.StringValue(name),
+			}, resp)
+
+			require.Equal(t, want, resp.RequiresReplace)
+		})
+	}
+}
+
+func TestRepositoryResourceStateKeepsNameCase(t *testing.T) {
+	r := &RepositoryResource{coveralls: &Coveralls{converter: repositoryConverter()}}
+
+	// created repositories take their id from the api
+	state := r.resourceState(&client.Repository{Service: "github", Name: "owner/repo"}, &RepositoryResourceState{
+		RepositoryState: RepositoryState{Id: types.StringUnknown(), Name: NewRepositoryNameValue("Owner/Repo")},
+	})
+
+	require.Equal(t, "Owner/Repo", state.Name.ValueString())
+	require.Equal(t, "github:owner/repo", state.Id.ValueString())
+	require.Equal(t, onDestroyAbandon, state.OnDestroy.ValueString())
+
+	// changing the case of the name keeps the id that was planned
+	state = r.resourceState(&client.Repository{Service: "github", Name: "owner/repo"}, &RepositoryResourceState{
+		RepositoryState: RepositoryState{Id: types.StringValue("github:owner/repo"), Name: NewRepositoryNameValue("OWNER/repo")},
+	})
+
+	require.Equal(t, "OWNER/repo", state.Name.ValueString())
+	require.Equal(t, "github:owner/repo", state.Id.ValueString())
+
+	// as does an api that changes the case of the name it returns
+	state = r.resourceState(&client.Repository{Service: "github", Name: "Owner/Repo"}, &RepositoryResourceState{
+		RepositoryState: RepositoryState{Id: types.StringValue("github:owner/repo"), Name: NewRepositoryNameValue("owner/repo")},
+	})
+
+	require.Equal(t, "owner/repo", state.Name.ValueString())
+	require.Equal(t, "github:owner/repo", state.Id.ValueString())
+
+	// imported repositories use the name returned by the api
+	state = r.resourceState(&client.Repository{Service: "github", Name: "owner/repo"}, &RepositoryResourceState{})
+
+	require.Equal(t, "owner/repo", state.Name.ValueString())
+	require.Equal(t, "github:owner/repo", state.Id.ValueString())
+}
+
+func TestRepositoryResourceCreate(t *testing.T) {
+	ctx := t.Context()
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	plan := testRepositoryResourceState(t, r, &RepositoryState{
+		Service:               types.StringValue("github"),
+		Name:                  NewRepositoryNameValue("owner/repo"),
+		CommentOnPullRequests: types.BoolValue(true),
+		SendBuildStatus:       types.BoolValue(false),
+		FailThreshold:         types.Float64Value(80),
+	})
+
+	resp := &fwresource.CreateResponse{State: tfsdk.State{Schema: plan.Schema}}
+	r.Create(ctx, fwresource.CreateRequest{Plan: tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+
+	stored, ok := api.Repository("github", "owner/repo")
+	require.True(t, ok)
+	require.True(t, stored.CommentOnPullRequests)
+	require.Equal(t, 80.0, *stored.FailThreshold)
+
+	state := &RepositoryResourceState{}
+	require.False(t, resp.State.Get(ctx, state).HasError())
+	require.Equal(t, "github:owner/repo", state.Id.ValueString())
+	require.Equal(t, stored.Token, state.Token.ValueString())
+	require.Equal(t, stored.CreatedAt, state.CreatedAt.ValueString())
+	require.True(t, state.FailChangeThreshold.IsNull())
+}
+
+// defaultsAPI records the repositories created, storing them with the defaults of an installation that doesn't
+// comment on pull requests.
+type defaultsAPI struct {
+	*fake.Coveralls
+	created *client.RepositoryCreate
+}
+
+func (a *defaultsAPI) Create(ctx context.Context, create *client.RepositoryCreate) (*client.Repository, error) {
+	a.created = create
+
+	repository, err := a.Coveralls.Create(ctx, create)
+	if err == nil && create.CommentOnPullRequests.IsZero() {
+		stored, _ := a.Repository(create.Service, create.Name)
+		stored.CommentOnPullRequests = false
+		a.AddRepository(stored)
+	}
+
+	return repository, err
+}
+
+func TestRepositoryResourceCreateUnconfiguredSettings(t *testing.T) {
+	ctx := t.Context()
+	coveralls, api := testFakeCoveralls()
+	defaults := &defaultsAPI{Coveralls: api}
+	coveralls.client = defaults
+	r := &RepositoryResource{coveralls: coveralls}
+
+	plan := testRepositoryResourceState(t, r, &RepositoryState{
+		Service:               types.StringValue("github"),
+		Name:                  NewRepositoryNameValue("owner/repo"),
+		CommentOnPullRequests: types.BoolUnknown(),
+		SendBuildStatus:       types.BoolValue(false),
+	})
+
+	resp := &fwresource.CreateResponse{State: tfsdk.State{Schema: plan.Schema}}
+	r.Create(ctx, fwresource.CreateRequest{Plan: tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+
+	// only the configured settings are sent
+	require.Equal(t, client.RepositoryUpdate{SendBuildStatus: client.FieldOf(false)}, defaults.created.RepositoryUpdate)
+
+	// settings that aren't configured get the default of the installation
+	state := &RepositoryResourceState{}
+	require.False(t, resp.State.Get(ctx, state).HasError())
+	require.False(t, state.CommentOnPullRequests.ValueBool())
+	require.False(t, state.SendBuildStatus.ValueBool())
+}
+
+func TestRepositoryResourceCreateError(t *testing.T) {
+	ctx := t.Context()
+	coveralls, api := testFakeCoveralls()
+	api.SetError(fake.OperationCreate, &client.APIError{StatusCode: http.StatusForbidden})
+	r := &RepositoryResource{coveralls: coveralls}
+
+	plan := testRepositoryResourceState(t, r, &RepositoryState{
+		Service: types.StringValue("github"),
+		Name:    NewRepositoryNameValue("owner/repo"),
+	})
+
+	resp := &fwresource.CreateResponse{State: tfsdk.State{Schema: plan.Schema}}
+	r.Create(ctx, fwresource.CreateRequest{Plan: tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}}, resp)
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.True(t, resp.State.Raw.IsNull())
+	require.Equal(t, 0, api.Calls(fake.OperationGet))
+}
+
+func TestRepositoryResourceRead(t *testing.T) {
+	ctx := t.Context()
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	threshold := 50.0
+	stored := api.AddRepository(&client.Repository{
+		Service:         "github",
+		Name:            "owner/repo",
+		SendBuildStatus: true,
+		FailThreshold:   &threshold,
+	})
+
+	state := testRepositoryResourceState(t, r, &RepositoryState{
+		Id:      types.StringValue("github:owner/repo"),
+		Service: types.StringValue("github"),
+		Name:    NewRepositoryNameValue("owner/repo"),
+	})
+
+	resp := &fwresource.ReadResponse{State: state}
+	r.Read(ctx, fwresource.ReadRequest{State: state}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+
+	got := &RepositoryResourceState{}
+	require.False(t, resp.State.Get(ctx, got).HasError())
+	require.True(t, got.SendBuildStatus.ValueBool())
+	require.Equal(t, 50.0, got.FailThreshold.ValueFloat64())
+	require.Equal(t, stored.Token, got.Token.ValueString())
+}
+
+func TestRepositoryResourceUpdate(t *testing.T) {
+	ctx := t.Context()
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	stored := api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	prior := testRepositoryResourceState(t, r, &RepositoryState{
+		Id:                    types.StringValue("github:owner/repo"),
+		Service:               types.StringValue("github"),
+		Name:                  NewRepositoryNameValue("owner/repo"),
+		CommentOnPullRequests: types.BoolValue(false),
+		SendBuildStatus:       types.BoolValue(false),
+	})
+
+	plan := testRepositoryResourceState(t, r, &RepositoryState{
+		Id:                    types.StringValue("github:owner/repo"),
+		Service:               types.StringValue("github"),
+		Name:                  NewRepositoryNameValue("owner/repo"),
+		CommentOnPullRequests: types.BoolValue(true),
+		SendBuildStatus:       types.BoolValue(true),
+		FailChangeThreshold:   types.Float64Value(2.5),
+	})
+
+	resp := &fwresource.UpdateResponse{State: tfsdk.State{Schema: plan.Schema}}
+	r.Update(ctx, fwresource.UpdateRequest{Plan: tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}, State: prior}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+
+	updated, _ := api.Repository("github", "owner/repo")
+	require.True(t, updated.CommentOnPullRequests)
+	require.True(t, updated.SendBuildStatus)
+	require.Equal(t, 2.5, *updated.FailChangeThreshold)
+
+	state := &RepositoryResourceState{}
+	require.False(t, resp.State.Get(ctx, state).HasError())
+	require.Equal(t, stored.Token, state.Token.ValueString())
+	require.Equal(t, 2.5, state.FailChangeThreshold.ValueFloat64())
+}
+
+func TestRepositoryResourceUpdateChangedOutside(t *testing.T) {
+	testConsistencyIntervals(t)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	// the threshold was changed in the Coveralls UI since the last refresh
+	threshold := 50.0
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo", FailThreshold: &threshold})
+
+	settings := &RepositoryState{
+		Id:                    types.StringValue("github:owner/repo"),
+		Service:               types.StringValue("github"),
+		Name:                  NewRepositoryNameValue("owner/repo"),
+		CommentOnPullRequests: types.BoolValue(false),
+		SendBuildStatus:       types.BoolValue(false),
+		FailThreshold:         types.Float64Value(80),
+	}
+	prior := testRepositoryResourceState(t, r, settings)
+
+	settings.SendBuildStatus = types.BoolValue(true)
+	plan := testRepositoryResourceState(t, r, settings)
+
+	resp := &fwresource.UpdateResponse{State: tfsdk.State{Schema: plan.Schema}}
+	r.Update(ctx, fwresource.UpdateRequest{Plan: tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}, State: prior}, resp)
+
+	// only the setting that was sent is waited for
+	require.False(t, resp.Diagnostics.HasError(), "%v", resp.Diagnostics)
+
+	updated, _ := api.Repository("github", "owner/repo")
+	require.True(t, updated.SendBuildStatus)
+	require.Equal(t, 50.0, *updated.FailThreshold)
+
+	state := &RepositoryResourceState{}
+	require.False(t, resp.State.Get(ctx, state).HasError())
+	require.Equal(t, 50.0, state.FailThreshold.ValueFloat64())
+}
+
+func TestRepositoryResourceUpdateUnchangedSettings(t *testing.T) {
+	ctx := t.Context()
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	threshold := 80.0
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo", FailThreshold: &threshold})
+
+	settings := &RepositoryState{
+		Id:                    types.StringValue("github:owner/repo"),
+		Service:               types.StringValue("github"),
+		Name:                  NewRepositoryNameValue("owner/repo"),
+		CommentOnPullRequests: types.BoolValue(false),
+		SendBuildStatus:       types.BoolValue(false),
+		FailThreshold:         types.Float64Value(80),
+	}
+
+	prior := testRepositoryResourceState(t, r, settings)
+	plan := testRepositoryResourceStateOnDestroy(t, r, settings, types.StringValue(onDestroyReset))
+
+	resp := &fwresource.UpdateResponse{State: tfsdk.State{Schema: plan.Schema}}
+	r.Update(ctx, fwresource.UpdateRequest{Plan: tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw}, State: prior}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+	require.Equal(t, 0, api.Calls(fake.OperationUpdate))
+
+	state := &RepositoryResourceState{}
+	require.False(t, resp.State.Get(ctx, state).HasError())
+	require.Equal(t, onDestroyReset, state.OnDestroy.ValueString())
+}
+
+func TestRepositoryUpdate(t *testing.T) {
+	prior := &RepositoryState{
+		CommentOnPullRequests: types.BoolValue(true),
+		SendBuildStatus:       types.BoolValue(true),
+		FailThreshold:         types.Float64Value(80),
+		FailChangeThreshold:   types.Float64Null(),
+	}
+
+	update := repositoryUpdate(prior, &RepositoryState{
+		CommentOnPullRequests: types.BoolValue(true),
+		SendBuildStatus:       types.BoolValue(false),
+		FailThreshold:         types.Float64Null(),
+		FailChangeThreshold:   types.Float64Unknown(),
+	})
+
+	// only the settings that changed are sent, a removed threshold is sent as null
+	require.Equal(t, &client.RepositoryUpdate{
+		SendBuildStatus: client.FieldOf(false),
+		FailThreshold:   client.NewField[float64](nil),
+	}, update)
+
+	require.True(t, repositoryUpdate(prior, prior).IsEmpty())
+}
+
+func TestParseRepositoryId(t *testing.T) {
+	for _, test := range []struct {
+		id             string
+		defaultService string
+		service        string
+		name           string
+		valid          bool
+	}{
+		{id: "github:owner/repo", service: "github", name: "owner/repo", valid: true},
+		{id: "gitlab:group/subgroup/repo", defaultService: "github", service: "gitlab", name: "group/subgroup/repo", valid: true},
+		{id: "owner/repo", defaultService: "github", service: "github", name: "owner/repo", valid: true},
+		{id: "owner/repo"},
+		{id: "githib:owner/repo"},
+		{id: "github:repo"},
+		{id: "github:"},
+		{id: ""},
+	} {
+		service, name, err := parseRepositoryId(test.id, test.defaultService)
+		require.Equal(t, test.valid, err == nil, "id %q: %v", test.id, err)
+		require.Equal(t, test.service, service)
+		require.Equal(t, test.name, name)
+	}
+}
+
+func TestRepositoryResourceImportState(t *testing.T) {
+	ctx := t.Context()
+	coveralls, _ := testFakeCoveralls()
+	coveralls.defaultService = "github"
+	r := &RepositoryResource{coveralls: coveralls}
+
+	empty := testRepositoryResourceState(t, r, &RepositoryState{})
+	empty.Raw = tftypes.NewValue(empty.Schema.Type().TerraformType(ctx), nil)
+
+	// the service may be left out of the id, falling back to the default
+	resp := &fwresource.ImportStateResponse{State: empty}
+	r.ImportState(ctx, fwresource.ImportStateRequest{ID: "owner/repo"}, resp)
+
+	require.False(t, resp.Diagnostics.HasError(), "%v", resp.Diagnostics)
+
+	var id types.String
+	require.False(t, resp.State.GetAttribute(ctx, path.Root("id"), &id).HasError())
+	require.Equal(t, "github:owner/repo", id.ValueString())
+
+	// an id that can't be parsed is reported rather than failing the read that follows
+	resp = &fwresource.ImportStateResponse{State: empty}
+	r.ImportState(ctx, fwresource.ImportStateRequest{ID: "repo"}, resp)
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.Equal(t, "Invalid import id", resp.Diagnostics.Errors()[0].Summary())
+}
+
+func TestRepositoryResourceReadInvalidId(t *testing.T) {
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	state := testRepositoryResourceState(t, r, &RepositoryState{Id: types.StringValue("owner/repo")})
+
+	resp := &fwresource.ReadResponse{State: state}
+	r.Read(t.Context(), fwresource.ReadRequest{State: state}, resp)
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.Equal(t, "Invalid repository id", resp.Diagnostics.Errors()[0].Summary())
+	require.Equal(t, 0, api.Calls(fake.OperationGet))
+}
+
+func TestRepositoryResourceDeleteAbandon(t *testing.T) {
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo", SendBuildStatus: false})
+
+	resp := testRepositoryResourceDelete(t, r, types.StringValue(onDestroyAbandon))
+
+	require.False(t, resp.Diagnostics.HasError())
+	require.Len(t, resp.Diagnostics.Warnings(), 1)
+	require.Equal(t, "Repository abandoned", resp.Diagnostics.Warnings()[0].Summary())
+
+	_, ok := api.Repository("github", "owner/repo")
+	require.True(t, ok)
+	require.Equal(t, 0, api.Calls(fake.OperationUpdate))
+	require.Equal(t, 0, api.Calls(fake.OperationDelete))
+}
+
+func TestRepositoryResourceDeleteReset(t *testing.T) {
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	threshold := 80.0
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo", FailThreshold: &threshold})
+
+	resp := testRepositoryResourceDelete(t, r, types.StringValue(onDestroyReset))
+
+	require.False(t, resp.Diagnostics.HasError())
+	require.Empty(t, resp.Diagnostics.Warnings())
+
+	stored, ok := api.Repository("github", "owner/repo")
+	require.True(t, ok)
+	require.True(t, stored.CommentOnPullRequests)
+	require.True(t, stored.SendBuildStatus)
+	require.Nil(t, stored.FailThreshold)
+}
+
+func TestRepositoryResourceDelete(t *testing.T) {
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+
+	resp := testRepositoryResourceDelete(t, r, types.StringValue(onDestroyDelete))
+
+	require.False(t, resp.Diagnostics.HasError())
+
+	_, ok := api.Repository("github", "owner/repo")
+	require.False(t, ok)
+
+	// deleting a repository that no longer exists succeeds
+	resp = testRepositoryResourceDelete(t, r, types.StringValue(onDestroyDelete))
+	require.False(t, resp.Diagnostics.HasError())
+}
+
+func TestRepositoryResourceDeleteNotFound(t *testing.T) {
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	// like an api without a delete endpoint
+	api.AddRepository(&client.Repository{Service: "github", Name: "owner/repo"})
+	api.SetError(fake.OperationDelete, &client.APIError{StatusCode: http.StatusNotFound})
+
+	resp := testRepositoryResourceDelete(t, r, types.StringValue(onDestroyDelete))
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.Equal(t, "Repository deletion not supported", resp.Diagnostics.Errors()[0].Summary())
+	require.Equal(t, 1, api.Calls(fake.OperationGet))
+
+	_, ok := api.Repository("github", "owner/repo")
+	require.True(t, ok)
+}
+
+func TestRepositoryResourceDeleteNotSupported(t *testing.T) {
+	coveralls, api := testFakeCoveralls()
+	r := &RepositoryResource{coveralls: coveralls}
+
+	api.SetError(fake.OperationDelete, &client.APIError{StatusCode: http.StatusMethodNotAllowed})
+
+	resp := testRepositoryResourceDelete(t, r, types.StringValue(onDestroyDelete))
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.Equal(t, "Repository deletion not supported", resp.Diagnostics.Errors()[0].Summary())
+}
+
+func testRepositoryResourceDelete(t *testing.T, r *RepositoryResource, onDestroy types.String) *fwresource.DeleteResponse {
+	state := testRepositoryResourceStateOnDestroy(t, r, &RepositoryState{
+		Id:      types.StringValue("github:owner/repo"),
+		Service: types.StringValue("github"),
+		Name:    NewRepositoryNameValue("owner/repo"),
+	}, onDestroy)
+
+	resp := &fwresource.DeleteResponse{State: state}
+	r.Delete(t.Context(), fwresource.DeleteRequest{State: state}, resp)
+
+	return resp
+}
+
+func testRepositoryResourceState(t *testing.T, r *RepositoryResource, repository *RepositoryState) tfsdk.State {
+	return testRepositoryResourceStateOnDestroy(t, r, repository, types.StringNull())
+}
+
+func testRepositoryResourceStateOnDestroy(t *testing.T, r *RepositoryResource, repository *RepositoryState, onDestroy types.String) tfsdk.State {
+	schemaResp := &fwresource.SchemaResponse{}
+	r.Schema(t.Context(), fwresource.SchemaRequest{}, schemaResp)
+
+	state := tfsdk.State{Schema: schemaResp.Schema}
+	require.False(t, state.Set(t.Context(), &RepositoryResourceState{
+		RepositoryState: *repository,
+		OnDestroy:       onDestroy,
+		Timeouts: timeouts.Value{
+			Object: types.ObjectNull(map[string]attr.Type{
+				"create": types.StringType,
+				"read":   types.StringType,
+				"update": types.StringType,
+				"delete": types.StringType,
+			}),
+		},
+	}).HasError())
+
+	return state
+}
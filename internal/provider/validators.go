@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	minThreshold = 0
+	maxThreshold = 100
+)
+
+var (
+	// knownServices are the git providers supported by Coveralls, 'stash' (Bitbucket Server) and the '_enterprise'
+	// services are only available on Coveralls Enterprise.
+	knownServices = []string{"github", "gitlab", "bitbucket", "stash", "github_enterprise", "gitlab_enterprise"}
+
+	// repositoryNamePattern matches '<owner>/<name>', where the owner may be a gitlab group with subgroups, eg:
+	// 'group/subgroup/name'.
+	repositoryNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)+$`)
+)
+
+// isKnownService returns true if the service is one of the knownServices.
+func isKnownService(service string) bool {
+	return slices.Contains(knownServices, service)
+}
+
+func serviceValidator() validator.String {
+	return stringvalidator.OneOf(knownServices...)
+}
+
+func repositoryNameValidator() validator.String {
+	return stringvalidator.RegexMatches(
+		repositoryNamePattern,
+		"must be in the form <owner>/<name>, where the owner may include subgroups, eg: 'group/subgroup/name'",
+	)
+}
+
+func thresholdValidator() validator.Float64 {
+	return float64validator.Between(minThreshold, maxThreshold)
+}
+
+// repositorySettingsRule checks a combination of configured repository settings, adding warnings for settings that
+// have no effect. None of the rules are errors, as no combination of the settings contradicts another: each threshold
+// fails the build status on its own, and either may be set whether or not the build status is sent.
+type repositorySettingsRule func(settings *RepositoryState) diag.Diagnostics
+
+// repositorySettingsRules are applied to the configuration of repository resources, settings read from the api aren't
+// checked as they may have been changed outside of Terraform.
+var repositorySettingsRules = []repositorySettingsRule{
+	thresholdsWithoutBuildStatus,
+}
+
+// validateRepositorySettings applies the repositorySettingsRules, values that are null or unknown are skipped.
+func validateRepositorySettings(settings *RepositoryState) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, rule := range repositorySettingsRules {
+		diags.Append(rule(settings)...)
+	}
+	return diags
+}
+
+// thresholdsWithoutBuildStatus warns about thresholds when build status isn't sent, as they only decide its result.
+func thresholdsWithoutBuildStatus(settings *RepositoryState) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if settings.SendBuildStatus.IsNull() || settings.SendBuildStatus.IsUnknown() || settings.SendBuildStatus.ValueBool() {
+		return diags
+	}
+
+	thresholds := []struct {
+		name  string
+		value types.Float64
+	}{
+		{"commit_status_fail_threshold", settings.FailThreshold},
+		{"commit_status_fail_change_threshold", settings.FailChangeThreshold},
+	}
+
+	for _, threshold := range thresholds {
+		if threshold.value.IsNull() || threshold.value.IsUnknown() {
+			continue
+		}
+
+		diags.AddAttributeWarning(
+			path.Root(threshold.name),
+			"Threshold has no effect",
+			fmt.Sprintf("The %s only decides the build status sent to the git provider, which is disabled as "+
+				"send_build_status is false.", threshold.name),
+		)
+	}
+
+	return diags
+}
+
+var _ resource.ConfigValidator = repositorySettingsValidator{}
+
+// repositorySettingsValidator applies the repositorySettingsRules to the configuration of a repository resource.
+type repositorySettingsValidator struct{}
+
+func (v repositorySettingsValidator) Description(ctx context.Context) string {
+	return v.MarkdownDescription(ctx)
+}
+
+func (v repositorySettingsValidator) MarkdownDescription(_ context.Context) string {
+	return "Checks the configured repository settings have an effect."
+}
+
+func (v repositorySettingsValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	config := &RepositoryResourceState{}
+	resp.Diagnostics.Append(req.Config.Get(ctx, config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateRepositorySettings(&config.RepositoryState)...)
+}
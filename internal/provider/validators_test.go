@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceValidator(t *testing.T) {
+	for service, valid := range map[string]bool{
+		"github":            true,
+		"gitlab":            true,
+		"bitbucket":         true,
+		"stash":             true,
+		"github_enterprise": true,
+		"gitlab_enterprise": true,
+		"GitHub":            false,
+		"githib":            false,
+		"":                  false,
+	} {
+		t.Run(service, func(t *testing.T) {
+			resp := &validator.StringResponse{}
+			serviceValidator().ValidateString(t.Context(), validator.StringRequest{
+				Path:        path.Root("service"),
+				ConfigValue: types.StringValue(service),
+			}, resp)
+			require.Equal(t, !valid, resp.Diagnostics.HasError())
+			require.Equal(t, valid, isKnownService(service))
+		})
+	}
+}
+
+func TestRepositoryNameValidator(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"owner/repo":                 true,
+		"Owner-1/repo_name.go":       true,
+		"group/subgroup/repo":        true,
+		"group/subgroup/nested/repo": true,
+		"repo":                       false,
+		"owner/":                     false,
+		"/repo":                      false,
+		"owner//repo":                false,
+		"owner/my repo":              false,
+		"https://github.com/o/r":     false,
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := &validator.StringResponse{}
+			repositoryNameValidator().ValidateString(t.Context(), validator.StringRequest{
+				Path:        path.Root("name"),
+				ConfigValue: types.StringValue(name),
+			}, resp)
+			require.Equal(t, !valid, resp.Diagnostics.HasError())
+		})
+	}
+}
+
+func TestThresholdValidator(t *testing.T) {
+	for _, test := range []struct {
+		threshold float64
+		valid     bool
+	}{
+		{0, true},
+		{50.5, true},
+		{100, true},
+		{-0.1, false},
+		{100.1, false},
+	} {
+		resp := &validator.Float64Response{}
+		thresholdValidator().ValidateFloat64(t.Context(), validator.Float64Request{
+			Path:        path.Root("commit_status_fail_threshold"),
+			ConfigValue: types.Float64Value(test.threshold),
+		}, resp)
+		require.Equal(t, !test.valid, resp.Diagnostics.HasError(), "threshold %v", test.threshold)
+	}
+}
+
+func TestValidateRepositorySettings(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		settings *RepositoryState
+		paths    []path.Path
+	}{
+		{
+			name: "build status sent",
+			settings: &RepositoryState{
+				SendBuildStatus: types.BoolValue(true),
+				FailThreshold:   types.Float64Value(80),
+			},
+		},
+		{
+			name: "no thresholds",
+			settings: &RepositoryState{
+				SendBuildStatus:     types.BoolValue(false),
+				FailThreshold:       types.Float64Null(),
+				FailChangeThreshold: types.Float64Null(),
+			},
+		},
+		{
+			name: "unknown build status",
+			settings: &RepositoryState{
+				SendBuildStatus: types.BoolUnknown(),
+				FailThreshold:   types.Float64Value(80),
+			},
+		},
+		{
+			name: "thresholds without build status",
+			settings: &RepositoryState{
+				SendBuildStatus:     types.BoolValue(false),
+				FailThreshold:       types.Float64Value(80),
+				FailChangeThreshold: types.Float64Value(5),
+			},
+			paths: []path.Path{
+				path.Root("commit_status_fail_threshold"),
+				path.Root("commit_status_fail_change_threshold"),
+			},
+		},
+		{
+			name: "unknown threshold",
+			settings: &RepositoryState{
+				SendBuildStatus:     types.BoolValue(false),
+				FailThreshold:       types.Float64Unknown(),
+				FailChangeThreshold: types.Float64Value(5),
+			},
+			paths: []path.Path{
+				path.Root("commit_status_fail_change_threshold"),
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			diags := validateRepositorySettings(test.settings)
+			require.False(t, diags.HasError())
+			require.Len(t, diags, len(test.paths))
+
+			for i, d := range diags {
+				require.Equal(t, diag.SeverityWarning, d.Severity())
+				require.Equal(t, test.paths[i], d.(diag.DiagnosticWithPath).Path())
+			}
+		})
+	}
+}
+
+func TestRepositorySettingsValidator(t *testing.T) {
+	r := &RepositoryResource{}
+
+	config := testRepositoryResourceState(t, r, &RepositoryState{
+		Name:            NewRepositoryNameValue("owner/repo"),
+		SendBuildStatus: types.BoolValue(false),
+		FailThreshold:   types.Float64Value(80),
+	})
+
+	validators := r.ConfigValidators(t.Context())
+	require.Len(t, validators, 1)
+
+	resp := &fwresource.ValidateConfigResponse{}
+	validators[0].ValidateResource(t.Context(), fwresource.ValidateConfigRequest{
+		Config: tfsdk.Config{Schema: config.Schema, Raw: config.Raw},
+	}, resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+	require.Equal(t, 1, resp.Diagnostics.WarningsCount())
+	require.Equal(t, "Threshold has no effect", resp.Diagnostics[0].Summary())
+}